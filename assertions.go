@@ -2,8 +2,8 @@ package httpfake
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
@@ -145,7 +145,7 @@ func (b *requiredBody) Assert(r *http.Request) error {
 		return fmt.Errorf("error reading the request body; the request body is nil")
 	}
 
-	body, err := ioutil.ReadAll(r.Body)
+	body, err := readBody(r)
 	if err != nil {
 		return fmt.Errorf("error reading the request body: %s", err.Error())
 	}
@@ -169,6 +169,154 @@ func (b *requiredBody) Error(t testing.TB, err error) {
 	t.Errorf(assertErrorTemplate, err)
 }
 
+// requiredJSONSchema provides an Assertor for a JSON request body valid for the expected JSON schema
+type requiredJSONSchema struct {
+	Schema []byte
+}
+
+// Assert runs the required JSON schema assertion against the provided request,
+// the error lists every violation of the schema found in the request body
+func (s *requiredJSONSchema) Assert(r *http.Request) error {
+	var schema interface{}
+	if err := json.Unmarshal(s.Schema, &schema); err != nil {
+		return fmt.Errorf("error parsing the JSON schema: %s", err.Error())
+	}
+
+	value, err := readJSONBody(r)
+	if err != nil {
+		return err
+	}
+
+	errs := validateSchema(schema, schema, value)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	violations := make([]string, len(errs))
+	for i, e := range errs {
+		violations[i] = e.Error()
+	}
+	return fmt.Errorf("request body does not match the JSON schema:\n* %s", strings.Join(violations, "\n* "))
+}
+
+// Log prints a testing info log for the requiredJSONSchema Assertor
+func (s *requiredJSONSchema) Log(t testing.TB) {
+	t.Log("Testing request for a body matching a JSON schema")
+}
+
+// Error prints a testing error for the requiredJSONSchema Assertor
+func (s *requiredJSONSchema) Error(t testing.TB, err error) {
+	t.Errorf(assertErrorTemplate, err)
+}
+
+// requiredJSON provides an Assertor for a JSON request body holding the same values as the expected JSON,
+// or only containing them when Subset is set
+type requiredJSON struct {
+	ExpectedBody []byte
+	Subset       bool
+}
+
+// Assert runs the required JSON assertion against the provided request,
+// the error lists every value of the request body which differs from the expected JSON
+func (j *requiredJSON) Assert(r *http.Request) error {
+	expected, err := decodeJSON(j.ExpectedBody)
+	if err != nil {
+		return fmt.Errorf("error parsing the expected JSON: %s", err.Error())
+	}
+
+	actual, err := readJSONBody(r)
+	if err != nil {
+		return err
+	}
+
+	if diff := jsonDiff(expected, actual, "$", j.Subset); len(diff) > 0 {
+		verb := "match"
+		if j.Subset {
+			verb = "contain"
+		}
+		return fmt.Errorf("request body does not %s the expected JSON:\n* %s", verb, strings.Join(diff, "\n* "))
+	}
+
+	return nil
+}
+
+// Log prints a testing info log for the requiredJSON Assertor
+func (j *requiredJSON) Log(t testing.TB) {
+	if j.Subset {
+		t.Log("Testing request for a JSON body containing the expected JSON")
+		return
+	}
+	t.Log("Testing request for a JSON body matching the expected JSON")
+}
+
+// Error prints a testing error for the requiredJSON Assertor
+func (j *requiredJSON) Error(t testing.TB, err error) {
+	t.Errorf(assertErrorTemplate, err)
+}
+
+// requiredJSONPath provides an Assertor for the value found at a path of a JSON request body, e.g. "$.users[0].id".
+// The expected value is compared to the JSON value after being encoded to JSON.
+type requiredJSONPath struct {
+	Path          string
+	ExpectedValue interface{}
+}
+
+// Assert runs the required JSON path assertion against the provided request
+func (j *requiredJSONPath) Assert(r *http.Request) error {
+	data, err := json.Marshal(j.ExpectedValue)
+	if err != nil {
+		return fmt.Errorf("error encoding the expected value: %s", err.Error())
+	}
+	expected, err := decodeJSON(data)
+	if err != nil {
+		return fmt.Errorf("error decoding the expected value: %s", err.Error())
+	}
+
+	body, err := readJSONBody(r)
+	if err != nil {
+		return err
+	}
+
+	actual, err := jsonPathValue(body, j.Path)
+	if err != nil {
+		return fmt.Errorf("request body does not have the expected value at %s: %s", j.Path, err.Error())
+	}
+
+	if diff := jsonDiff(expected, actual, j.Path, false); len(diff) > 0 {
+		return fmt.Errorf("request body does not have the expected value at %s:\n* %s", j.Path, strings.Join(diff, "\n* "))
+	}
+
+	return nil
+}
+
+// Log prints a testing info log for the requiredJSONPath Assertor
+func (j *requiredJSONPath) Log(t testing.TB) {
+	t.Logf("Testing request for a required JSON body value [%s: %s]", j.Path, formatJSON(j.ExpectedValue))
+}
+
+// Error prints a testing error for the requiredJSONPath Assertor
+func (j *requiredJSONPath) Error(t testing.TB, err error) {
+	t.Errorf(assertErrorTemplate, err)
+}
+
+// readJSONBody reads and decodes the JSON body of the request for the assertors
+func readJSONBody(r *http.Request) (interface{}, error) {
+	if r.Body == nil {
+		return nil, fmt.Errorf("error reading the request body; the request body is nil")
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the request body: %s", err.Error())
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return nil, fmt.Errorf("request body is not valid JSON: %s", err.Error())
+	}
+	return value, nil
+}
+
 // CustomAssertor provides a function signature that implements the Assertor interface. This allows for
 // adhoc creation of a custom assertion for use with the AssertCustom assertor.
 type CustomAssertor func(r *http.Request) error
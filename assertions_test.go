@@ -266,6 +266,216 @@ func TestAssertors_Assert(t *testing.T) {
 			},
 			expectedErr: "error reading the request body; the request body is nil",
 		},
+		{
+			name: "requiredJSONSchema should return no error with a proper request",
+			assertor: &requiredJSONSchema{
+				Schema: []byte(`{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`{"id": 1, "createdAt": "2006-01-02T15:04:05Z"}`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "",
+		},
+		{
+			name: "requiredJSONSchema should return an error with every violation of the schema",
+			assertor: &requiredJSONSchema{
+				Schema: []byte(`{
+					"type": "object",
+					"required": ["id", "tags"],
+					"properties": {
+						"id": {"type": "integer"},
+						"items": {"type": "array", "items": {"$ref": "#/definitions/item"}}
+					},
+					"definitions": {"item": {"type": "object", "required": ["sku"]}}
+				}`),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`{"id": "1", "items": [{"sku": "a"}, {}]}`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "request body does not match the JSON schema:\n" +
+				"* $: missing required property \"tags\"\n" +
+				"* $.id: expected integer, got string\n" +
+				"* $.items[1]: missing required property \"sku\"",
+		},
+		{
+			name: "requiredJSONSchema should return an error if the body is not JSON",
+			assertor: &requiredJSONSchema{
+				Schema: []byte(`{"type": "object"}`),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`id=1`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "request body is not valid JSON: invalid character 'i' looking for beginning of value",
+		},
+		{
+			name: "requiredJSON should return no error with a proper request",
+			assertor: &requiredJSON{
+				ExpectedBody: []byte(`{"id": 1, "roles": ["admin"]}`),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`{"roles":["admin"],"id":1}`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "",
+		},
+		{
+			name: "requiredJSON should return an error with every value which differs",
+			assertor: &requiredJSON{
+				ExpectedBody: []byte(`{"id": 1, "roles": ["admin"]}`),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`{"id": 2, "roles": ["user"], "username": "dreamer"}`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "request body does not match the expected JSON:\n" +
+				"* $.id: expected 1, got 2\n" +
+				"* $.roles[0]: expected \"admin\", got \"user\"\n" +
+				"* $.username: unexpected \"dreamer\"",
+		},
+		{
+			name: "requiredJSON should return no error if the body contains the expected JSON with Subset",
+			assertor: &requiredJSON{
+				ExpectedBody: []byte(`{"roles": ["admin"]}`),
+				Subset:       true,
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`{"id": 1, "roles": ["admin", "user"]}`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "",
+		},
+		{
+			name: "requiredJSON should return an error if the body does not contain the expected JSON with Subset",
+			assertor: &requiredJSON{
+				ExpectedBody: []byte(`{"user": {"username": "dreamer"}}`),
+				Subset:       true,
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`{"id": 1, "user": {}}`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "request body does not contain the expected JSON:\n" +
+				"* $.user.username: expected \"dreamer\", got nothing",
+		},
+		{
+			name: "requiredJSONPath should return no error with a proper request",
+			assertor: &requiredJSONPath{
+				Path:          "$.users[0].roles",
+				ExpectedValue: []string{"admin"},
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`{"users": [{"id": 1, "roles": ["admin"]}]}`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "",
+		},
+		{
+			name: "requiredJSONPath should return an error if the value differs",
+			assertor: &requiredJSONPath{
+				Path:          "$.users[0]",
+				ExpectedValue: map[string]interface{}{"id": 1, "roles": []string{"admin"}},
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`{"users": [{"id": 1, "roles": ["user"]}]}`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "request body does not have the expected value at $.users[0]:\n" +
+				"* $.users[0].roles[0]: expected \"admin\", got \"user\"",
+		},
+		{
+			name: "requiredJSONPath should return an error if a large integer differs",
+			assertor: &requiredJSONPath{
+				Path:          "$.id",
+				ExpectedValue: int64(9007199254740993),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`{"id": 9007199254740992}`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "request body does not have the expected value at $.id:\n" +
+				"* $.id: expected 9007199254740993, got 9007199254740992",
+		},
+		{
+			name: "requiredJSONPath should return an error if the path is missing",
+			assertor: &requiredJSONPath{
+				Path:          "$.users[1].id",
+				ExpectedValue: 2,
+			},
+			requestBuilder: func() (*http.Request, error) {
+				reader := bytes.NewBuffer([]byte(`{"users": [{"id": 1}]}`))
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", reader)
+				if err != nil {
+					return nil, err
+				}
+
+				return testReq, nil
+			},
+			expectedErr: "request body does not have the expected value at $.users[1].id: $.users[1] is missing",
+		},
 		{
 			name: "CustomAssertor should execute the custom assertor as expected",
 			assertor: CustomAssertor(func(r *http.Request) error {
@@ -371,6 +581,30 @@ func TestAssertors_Log(t *testing.T) {
 			assertor: &requiredBody{},
 			expected: "Testing request for a required body value\n",
 		},
+		{
+			name: "requiredJSONSchema Log should log the expected output when called",
+			mockTester: &mockTester{
+				buf: &bytes.Buffer{},
+			},
+			assertor: &requiredJSONSchema{},
+			expected: "Testing request for a body matching a JSON schema\n",
+		},
+		{
+			name: "requiredJSON Log should log the expected output when called",
+			mockTester: &mockTester{
+				buf: &bytes.Buffer{},
+			},
+			assertor: &requiredJSON{Subset: true},
+			expected: "Testing request for a JSON body containing the expected JSON\n",
+		},
+		{
+			name: "requiredJSONPath Log should log the expected output when called",
+			mockTester: &mockTester{
+				buf: &bytes.Buffer{},
+			},
+			assertor: &requiredJSONPath{Path: "$.id", ExpectedValue: 1},
+			expected: "Testing request for a required JSON body value [$.id: 1]",
+		},
 		{
 			name: "CustomAssertor Log should log the expected output when called",
 			mockTester: &mockTester{
@@ -441,6 +675,30 @@ func TestAssertors_Error(t *testing.T) {
 			assertor: &requiredBody{},
 			expected: "assertion error: test error",
 		},
+		{
+			name: "requiredJSONSchema Log should log the expected output when called",
+			mockTester: &mockTester{
+				buf: &bytes.Buffer{},
+			},
+			assertor: &requiredJSONSchema{},
+			expected: "assertion error: test error",
+		},
+		{
+			name: "requiredJSON Log should log the expected output when called",
+			mockTester: &mockTester{
+				buf: &bytes.Buffer{},
+			},
+			assertor: &requiredJSON{},
+			expected: "assertion error: test error",
+		},
+		{
+			name: "requiredJSONPath Log should log the expected output when called",
+			mockTester: &mockTester{
+				buf: &bytes.Buffer{},
+			},
+			assertor: &requiredJSONPath{},
+			expected: "assertion error: test error",
+		},
 		{
 			name: "CustomAssertor Log should log the expected output when called",
 			mockTester: &mockTester{
@@ -0,0 +1,82 @@
+package httpfake
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// defaultMaxBodySize is the size cap of the request bodies buffered by the fake server
+// when it is not set with WithMaxBodySize
+const defaultMaxBodySize = 10 << 20
+
+// errBodyTooLarge is returned when a request body is larger than the size cap of the fake server
+var errBodyTooLarge = errors.New("request body too large")
+
+// bufferBody reads the whole request body once, up to maxSize bytes when maxSize is positive.
+// The body is kept in the request context so a fresh reader can be set to the request
+// for every matcher, assertor and responder.
+func bufferBody(r *http.Request, maxSize int64) (*http.Request, []byte, error) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var reader io.Reader = r.Body
+		if maxSize > 0 {
+			// one more byte is read to find out if the body is over the cap
+			reader = io.LimitReader(r.Body, maxSize+1)
+		}
+
+		var err error
+		body, err = ioutil.ReadAll(reader)
+		r.Body.Close() // nolint errcheck
+		if err != nil {
+			return r, nil, err
+		}
+		if maxSize > 0 && int64(len(body)) > maxSize {
+			return r, nil, errBodyTooLarge
+		}
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), bodyKey, body))
+	resetBody(r)
+	return r, body, nil
+}
+
+// resetBody sets a fresh reader of the body buffered by the fake server to the request,
+// it does nothing for the requests which were not received by the fake server
+func resetBody(r *http.Request) {
+	body, ok := r.Context().Value(bodyKey).([]byte)
+	if !ok {
+		return
+	}
+
+	if len(body) == 0 {
+		r.Body = http.NoBody
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+}
+
+// readBody reads the whole request body and restores it
+// so it can still be read afterwards by the assertors and responders
+func readBody(r *http.Request) ([]byte, error) {
+	if body, ok := r.Context().Value(bodyKey).([]byte); ok {
+		resetBody(r)
+		return body, nil
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close() // nolint errcheck
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
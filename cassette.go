@@ -0,0 +1,220 @@
+package httpfake
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"unicode/utf8"
+)
+
+// interaction is a request and the response sent back to it
+type interaction struct {
+	method       string
+	url          string
+	body         []byte
+	status       int
+	header       http.Header
+	responseBody []byte
+}
+
+// registerInteractions registers request handlers replaying the given interactions.
+// The interactions for the same method, URL and body are replayed in order by a single handler,
+// which repeats the last response once they are over.
+func (f *HTTPFake) registerInteractions(interactions []interaction) {
+	handlers := map[string]*Request{}
+	for _, it := range interactions {
+		key := it.method + " " + it.url + "\n" + string(it.body)
+		rh, ok := handlers[key]
+		if ok {
+			rh.Response.Then()
+		} else {
+			// the replayed interactions are not expected to be called
+			rh = f.NewHandler().ExpectAtLeast(0)
+			rh.method(it.method, it.url)
+			if len(it.body) > 0 {
+				rh.MatchBody(it.body)
+			}
+			handlers[key] = rh
+		}
+
+		res := rh.Response
+		res.Status(it.status)
+		for key, values := range it.header {
+			for _, value := range values {
+				res.AddHeader(key, value)
+			}
+		}
+		res.Body(it.responseBody)
+	}
+}
+
+// cassette is the file format storing the interactions recorded with WithRecording
+type cassette struct {
+	Interactions []cassetteInteraction `json:"interactions"`
+}
+
+type cassetteInteraction struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+type cassetteRequest struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Base64Body string      `json:"base64Body,omitempty"`
+}
+
+type cassetteResponse struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Base64Body string      `json:"base64Body,omitempty"`
+}
+
+// encodeCassetteBody returns the body as a string, or encoded as base64 when it is not valid UTF-8
+func encodeCassetteBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return "", base64.StdEncoding.EncodeToString(body)
+}
+
+func decodeCassetteBody(body, base64Body string) ([]byte, error) {
+	if base64Body != "" {
+		return base64.StdEncoding.DecodeString(base64Body)
+	}
+	if body == "" {
+		return nil, nil
+	}
+	return []byte(body), nil
+}
+
+// LoadCassette registers request handlers replaying the interactions of a cassette file recorded with WithRecording.
+// The requests are matched by method, URL (path and query string) and body, and the repeated requests are
+// responded in the recorded order. The handlers are not expected to be called by Close.
+// Example:
+//     fakeService.LoadCassette("testdata/users.json")
+func (f *HTTPFake) LoadCassette(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("loading cassette %s: %w", path, err)
+	}
+
+	interactions := make([]interaction, 0, len(c.Interactions))
+	for i, ci := range c.Interactions {
+		body, err := decodeCassetteBody(ci.Request.Body, ci.Request.Base64Body)
+		if err != nil {
+			return fmt.Errorf("loading cassette %s: interaction %d: invalid request body: %w", path, i, err)
+		}
+		responseBody, err := decodeCassetteBody(ci.Response.Body, ci.Response.Base64Body)
+		if err != nil {
+			return fmt.Errorf("loading cassette %s: interaction %d: invalid response body: %w", path, i, err)
+		}
+
+		interactions = append(interactions, interaction{
+			method:       ci.Request.Method,
+			url:          ci.Request.URL,
+			body:         body,
+			status:       ci.Response.Status,
+			header:       ci.Response.Header,
+			responseBody: responseBody,
+		})
+	}
+
+	f.registerInteractions(interactions)
+	return nil
+}
+
+// recorder forwards the requests to the upstream server and records the interactions
+type recorder struct {
+	upstream *url.URL
+	path     string
+
+	mu           sync.Mutex
+	interactions []cassetteInteraction
+}
+
+func newRecorder(upstream, path string) *recorder {
+	u, err := url.Parse(upstream)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		panic(fmt.Sprintf("setup error: \"WithRecording\" requires an absolute upstream URL, got %q", upstream))
+	}
+	if path == "" {
+		panic("setup error: \"WithRecording\" requires the path of the cassette file")
+	}
+	return &recorder{upstream: u, path: path}
+}
+
+// forward proxies the request to the upstream server and records it along with the upstream response
+func (rec *recorder) forward(w http.ResponseWriter, r *http.Request, body []byte) {
+	proxy := httputil.NewSingleHostReverseProxy(rec.upstream)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = rec.upstream.Host
+	}
+
+	proxy.ModifyResponse = func(res *http.Response) error {
+		responseBody, err := ioutil.ReadAll(res.Body)
+		res.Body.Close() // nolint errcheck
+		if err != nil {
+			return err
+		}
+		res.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+		ci := cassetteInteraction{
+			Request: cassetteRequest{
+				Method: r.Method,
+				URL:    r.URL.RequestURI(),
+				Header: r.Header.Clone(),
+			},
+			Response: cassetteResponse{
+				Status: res.StatusCode,
+				Header: res.Header.Clone(),
+			},
+		}
+		ci.Request.Body, ci.Request.Base64Body = encodeCassetteBody(body)
+		ci.Response.Body, ci.Response.Base64Body = encodeCassetteBody(responseBody)
+
+		rec.mu.Lock()
+		rec.interactions = append(rec.interactions, ci)
+		rec.mu.Unlock()
+		return nil
+	}
+
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		reportError(r, fmt.Sprintf("error forwarding request [%s: %s] to %s: %v", r.Method, r.URL, rec.upstream, err))
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	proxy.ServeHTTP(w, r)
+}
+
+// save writes the recorded interactions to the cassette file
+func (rec *recorder) save() error {
+	rec.mu.Lock()
+	c := cassette{Interactions: rec.interactions}
+	if c.Interactions == nil {
+		c.Interactions = []cassetteInteraction{}
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	rec.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(rec.path, append(data, '\n'), 0644)
+}
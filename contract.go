@@ -0,0 +1,334 @@
+package httpfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// contract validates the requests and responses of the fake server against an OpenAPI document
+type contract struct {
+	api *openAPIDoc
+}
+
+func newContract(path string) *contract {
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		var api *openAPIDoc
+		if api, err = parseOpenAPI(data); err == nil {
+			return &contract{api: api}
+		}
+	}
+
+	panic(fmt.Sprintf("setup error: \"WithOpenAPIValidation\" could not load the OpenAPI document %s: %v", path, err))
+}
+
+// findOperation returns the operation of the document for the request and the values of its path parameters.
+// The operations for concrete paths are preferred over the operations for path templates.
+func (c *contract) findOperation(r *http.Request) (*openAPIOperation, map[string]string, error) {
+	var found *openAPIOperation
+	var foundParams map[string]string
+	pathFound := false
+	for _, op := range c.api.operations {
+		params, ok := op.matchPath(r.URL.Path)
+		if !ok {
+			continue
+		}
+		pathFound = true
+
+		if op.method == r.Method && (found == nil || len(op.params) < len(found.params)) {
+			found, foundParams = op, params
+		}
+	}
+
+	switch {
+	case found != nil:
+		return found, foundParams, nil
+	case pathFound:
+		return nil, nil, fmt.Errorf("method %s is not allowed for the path", r.Method)
+	default:
+		return nil, nil, fmt.Errorf("path %s is not defined", r.URL.Path)
+	}
+}
+
+// validateRequest fails the test with the violations of the contract by the incoming request
+func (c *contract) validateRequest(t testing.TB, r *http.Request, body []byte) {
+	op, pathParams, err := c.findOperation(r)
+	if err != nil {
+		c.report(t, "request", r, []string{err.Error()})
+		return
+	}
+
+	var violations []string
+	for _, param := range op.parameters {
+		violations = append(violations, c.validateParam(param, r, pathParams)...)
+	}
+
+	if op.requestBody != nil {
+		content, _ := op.requestBody["content"].(map[string]interface{})
+		switch {
+		case len(body) == 0 && op.requestBody["required"] == true:
+			violations = append(violations, "missing required request body")
+		case len(body) > 0:
+			violations = append(violations, c.validateContent("request body", content, r.Header, body)...)
+		}
+	}
+
+	c.report(t, "request", r, violations)
+}
+
+// validateParam validates a path, query, header or cookie parameter of the request
+func (c *contract) validateParam(param map[string]interface{}, r *http.Request, pathParams map[string]string) []string {
+	name, _ := param["name"].(string)
+	in, _ := param["in"].(string)
+
+	var values []string
+	switch in {
+	case "path":
+		if value, ok := pathParams[name]; ok {
+			values = []string{value}
+		}
+	case "query":
+		values = r.URL.Query()[name]
+	case "header":
+		// these headers are described by other fields of the document and ignored as parameters
+		switch http.CanonicalHeaderKey(name) {
+		case "Accept", "Content-Type", "Authorization":
+			return nil
+		}
+		values = r.Header[http.CanonicalHeaderKey(name)]
+	case "cookie":
+		if cookie, err := r.Cookie(name); err == nil {
+			values = []string{cookie.Value}
+		}
+	}
+
+	if len(values) == 0 {
+		if param["required"] == true || in == "path" {
+			return []string{fmt.Sprintf("missing required %s parameter %q", in, name)}
+		}
+		return nil
+	}
+
+	schema, ok := param["schema"]
+	if !ok {
+		return nil
+	}
+
+	var violations []string
+	for _, e := range validateSchema(c.api.root, schema, c.paramValue(schema, values)) {
+		violations = append(violations,
+			fmt.Sprintf("%s parameter %q%s: %s", in, name, strings.TrimPrefix(e.Path, "$"), e.Message))
+	}
+	return violations
+}
+
+// paramValue converts the values of a parameter to the type set by its schema,
+// the values which can not be converted are kept as strings so the validation reports them
+func (c *contract) paramValue(schema interface{}, values []string) interface{} {
+	resolved, err := resolveSchema(c.api.root, schema)
+	if err != nil {
+		return values[0]
+	}
+
+	if schemaType(resolved) != "array" {
+		return paramScalar(resolved, values[0])
+	}
+
+	// the arrays are either repeated parameters or comma separated values
+	if len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+	items, _ := resolveSchema(c.api.root, resolved["items"])
+	list := make([]interface{}, len(values))
+	for i, value := range values {
+		list[i] = paramScalar(items, value)
+	}
+	return list
+}
+
+func paramScalar(schema map[string]interface{}, value string) interface{} {
+	switch schemaType(schema) {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// validateContent validates a request or response body against the media type matching its content type
+func (c *contract) validateContent(subject string, content map[string]interface{}, header http.Header, body []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+
+	// the bodies without a content type are validated against the preferred media type of the document
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = preferredMediaType(content)
+	}
+	mediaType := findMediaType(content, contentType)
+	if mediaType == "" {
+		return []string{fmt.Sprintf("%s content type %q is not defined", subject, contentType)}
+	}
+
+	media, err := c.api.resolve(content[mediaType])
+	if err != nil {
+		return []string{fmt.Sprintf("%s media type %s: %v", subject, mediaType, err)}
+	}
+	schema, ok := media["schema"]
+	if !ok || !isJSONMediaType(contentType) {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []string{fmt.Sprintf("%s is not valid JSON: %v", subject, err)}
+	}
+
+	var violations []string
+	for _, e := range validateSchema(c.api.root, schema, value) {
+		violations = append(violations, fmt.Sprintf("%s %s", subject, e))
+	}
+	return violations
+}
+
+// findMediaType returns the media type of the content matching the content type,
+// trying the exact media type and then the media type ranges, e.g. "application/*"
+func findMediaType(content map[string]interface{}, contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+
+	candidates := []string{mediaType, strings.Split(mediaType, "/")[0] + "/*", "*/*"}
+	for _, candidate := range candidates {
+		for key := range content {
+			if strings.EqualFold(strings.TrimSpace(strings.Split(key, ";")[0]), candidate) {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// validateResponse fails the test with the violations of the contract by the response sent to the request
+func (c *contract) validateResponse(t testing.TB, r *http.Request, w *responseRecorder) {
+	if w.hijacked {
+		return
+	}
+
+	op, _, err := c.findOperation(r)
+	if err != nil {
+		// already reported when validating the request
+		return
+	}
+
+	status, header := w.result()
+	c.report(t, "response", r, c.validateResponseParts(op, status, header, w.body.Bytes()))
+}
+
+// validateHandler fails the test with the violations of the contract by the responses configured for the request
+// handler, so the handlers which are never called can not drift from the contract unnoticed either.
+// The handlers set for any method, for a regular expression or with a custom responder are skipped,
+// as well as the bodies of the templated responses, which are only known once the response is sent.
+func (c *contract) validateHandler(t testing.TB, rh *Request) {
+	if rh.Method == anyMethod || rh.CustomHandle != nil {
+		return
+	}
+	if pattern, ok := rh.route.(*pathPattern); rh.route != nil && (!ok || pattern.wildcard) {
+		return
+	}
+
+	path := rh.URL.Path
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	op, _, err := c.findOperation(&http.Request{Method: rh.Method, URL: &url.URL{Path: path}})
+	if err != nil {
+		t.Errorf("httpfake: request handler %s does not match the OpenAPI contract:\n* %s", rh.describe(), err)
+		return
+	}
+
+	for i, res := range rh.responses {
+		if res.fault != 0 {
+			continue
+		}
+
+		status := res.StatusCode
+		if status == 0 {
+			status = http.StatusOK
+		}
+		body := res.BodyBuffer
+		if res.templated {
+			body = nil
+		}
+
+		violations := c.validateResponseParts(op, status, res.Header, body)
+		if len(violations) > 0 {
+			t.Errorf("httpfake: response %d of request handler %s does not match the OpenAPI contract:\n* %s",
+				i+1, rh.describe(), strings.Join(violations, "\n* "))
+		}
+	}
+}
+
+// validateResponseParts returns the violations of the contract by the status, headers and body of a response
+func (c *contract) validateResponseParts(op *openAPIOperation, status int, header http.Header, body []byte) []string {
+	node, ok := op.responses[strconv.Itoa(status)]
+	if !ok {
+		node, ok = op.responses[fmt.Sprintf("%dXX", status/100)]
+	}
+	if !ok {
+		node, ok = op.responses[fmt.Sprintf("%dxx", status/100)]
+	}
+	if !ok {
+		node, ok = op.responses["default"]
+	}
+	if !ok {
+		return []string{fmt.Sprintf("status %d is not defined", status)}
+	}
+
+	response, err := c.api.resolve(node)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var violations []string
+	headers, _ := response["headers"].(map[string]interface{})
+	for _, name := range sortedKeys(headers) {
+		h, err := c.api.resolve(headers[name])
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("response header %s: %v", name, err))
+			continue
+		}
+		if h["required"] == true && header.Get(name) == "" {
+			violations = append(violations, fmt.Sprintf("missing required response header %q", name))
+		}
+	}
+
+	if len(body) > 0 {
+		content, _ := response["content"].(map[string]interface{})
+		violations = append(violations, c.validateContent("response body", content, header, body)...)
+	}
+	return violations
+}
+
+func (c *contract) report(t testing.TB, subject string, r *http.Request, violations []string) {
+	if len(violations) == 0 {
+		return
+	}
+
+	t.Errorf("httpfake: %s for [%s: %s] does not match the OpenAPI contract:\n* %s",
+		subject, r.Method, r.URL, strings.Join(violations, "\n* "))
+}
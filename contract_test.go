@@ -0,0 +1,233 @@
+package httpfake
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const contractOpenAPI = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Users", "version": "1.0"},
+	"paths": {
+		"/users": {
+			"get": {
+				"parameters": [{"name": "page", "in": "query", "required": true, "schema": {"type": "integer", "minimum": 1}}],
+				"responses": {
+					"200": {
+						"description": "the users",
+						"headers": {"X-Total": {"required": true, "schema": {"type": "integer"}}},
+						"content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/User"}}}}
+					}
+				}
+			},
+			"post": {
+				"requestBody": {
+					"required": true,
+					"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}
+				},
+				"responses": {"201": {"description": "the created user"}}
+			}
+		},
+		"/users/{id}": {
+			"get": {
+				"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+				"responses": {
+					"2XX": {"description": "the user", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}
+				}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"User": {
+				"type": "object",
+				"required": ["id", "username"],
+				"properties": {"id": {"type": "integer"}, "username": {"type": "string"}}
+			}
+		}
+	}
+}`
+
+func TestOpenAPIValidation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+
+	path := filepath.Join(dir, "users.json")
+	if err := ioutil.WriteFile(path, []byte(contractOpenAPI), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mt := &mockTester{buf: &bytes.Buffer{}}
+	fakeService := New(WithTesting(mt), WithOpenAPIValidation(path))
+	defer fakeService.Server.Close()
+
+	fakeService.NewHandler().
+		Get("/users").
+		Reply(200).
+		SetHeader("X-Total", "1").
+		BodyString(`[{"id": 1, "username": "dreamer"}]`)
+
+	fakeService.NewHandler().
+		Post("/users").
+		Reply(201)
+
+	fakeService.NewHandler().
+		Get("/users/1").
+		Reply(200).
+		BodyString(`{"id": "1"}`)
+
+	fakeService.NewHandler().
+		Any("/clients").
+		Reply(200)
+
+	testCases := []struct {
+		method   string
+		path     string
+		body     string
+		expected string
+	}{
+		{
+			method:   http.MethodGet,
+			path:     "/users?page=1",
+			expected: "",
+		},
+		{
+			method: http.MethodGet,
+			path:   "/users?page=0",
+			expected: "httpfake: request for [GET: /users?page=0] does not match the OpenAPI contract:\n" +
+				"* query parameter \"page\": must be at least 1, got 0",
+		},
+		{
+			method: http.MethodGet,
+			path:   "/users",
+			expected: "httpfake: request for [GET: /users] does not match the OpenAPI contract:\n" +
+				"* missing required query parameter \"page\"",
+		},
+		{
+			method:   http.MethodPost,
+			path:     "/users",
+			body:     `{"id": 1, "username": "dreamer"}`,
+			expected: "",
+		},
+		{
+			method: http.MethodPost,
+			path:   "/users",
+			body:   `{"id": 1}`,
+			expected: "httpfake: request for [POST: /users] does not match the OpenAPI contract:\n" +
+				"* request body $: missing required property \"username\"",
+		},
+		{
+			method: http.MethodGet,
+			path:   "/users/1",
+			expected: "httpfake: response for [GET: /users/1] does not match the OpenAPI contract:\n" +
+				"* response body $: missing required property \"username\"\n" +
+				"* response body $.id: expected integer, got string",
+		},
+		{
+			method: http.MethodPut,
+			path:   "/users",
+			expected: "httpfake: request for [PUT: /users] does not match the OpenAPI contract:\n" +
+				"* method PUT is not allowed for the path",
+		},
+		{
+			method: http.MethodGet,
+			path:   "/clients",
+			expected: "httpfake: request for [GET: /clients] does not match the OpenAPI contract:\n" +
+				"* path /clients is not defined",
+		},
+	}
+
+	for _, tc := range testCases {
+		mt.buf.Reset()
+
+		req, err := http.NewRequest(tc.method, fakeService.ResolveURL(tc.path), strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close() // nolint errcheck
+
+		if msg := mt.buf.String(); msg != tc.expected {
+			t.Errorf("[%s: %s] returned unexpected error message: got %q want %q", tc.method, tc.path, msg, tc.expected)
+		}
+	}
+}
+
+func TestOpenAPIValidationHandlers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+
+	path := filepath.Join(dir, "users.json")
+	if err := ioutil.WriteFile(path, []byte(contractOpenAPI), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mt := &mockTester{buf: &bytes.Buffer{}}
+	fakeService := New(WithTesting(mt), WithOpenAPIValidation(path))
+
+	// none of the handlers is called, their responses are validated on Close
+	fakeService.NewHandler().
+		Get("/users?page=1").
+		ExpectAtLeast(0).
+		Reply(200).
+		BodyString(`[{"id": 1, "username": "dreamer"}]`)
+
+	fakeService.NewHandler().
+		Get("/users/{id}").
+		ExpectAtLeast(0).
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`{"id": 1, "username": "dreamer"}`).
+		Then().
+		Reply(200).
+		BodyString(`{"id": 1}`).
+		Then().
+		Reply(404)
+
+	fakeService.NewHandler().
+		Post("/users").
+		ExpectAtLeast(0).
+		Reply(201)
+
+	fakeService.NewHandler().
+		Delete("/users").
+		ExpectAtLeast(0).
+		Reply(204)
+
+	fakeService.NewHandler().
+		Any("/clients").
+		ExpectAtLeast(0).
+		Reply(200)
+
+	fakeService.Close()
+
+	expected := "httpfake: response 1 of request handler [GET: /users?page=1] does not match the OpenAPI contract:\n" +
+		"* missing required response header \"X-Total\"" +
+		"httpfake: response 2 of request handler [GET: /users/{id}] does not match the OpenAPI contract:\n" +
+		"* response body $: missing required property \"username\"" +
+		"httpfake: response 3 of request handler [GET: /users/{id}] does not match the OpenAPI contract:\n" +
+		"* status 404 is not defined" +
+		"httpfake: request handler [DELETE: /users] does not match the OpenAPI contract:\n" +
+		"* method DELETE is not allowed for the path"
+	if msg := mt.buf.String(); msg != expected {
+		t.Errorf("Close() returned unexpected error message: got %q want %q", msg, expected)
+	}
+}
@@ -0,0 +1,45 @@
+package httpfake
+
+import (
+	"fmt"
+)
+
+// callExpectation stores the expected range for the number of calls to a request handler
+// A negative max means there is no upper limit.
+type callExpectation struct {
+	min int
+	max int
+}
+
+// verify checks if the given number of calls is within the expected range
+func (e *callExpectation) verify(called int) error {
+	if called >= e.min && (e.max < 0 || called <= e.max) {
+		return nil
+	}
+
+	if e.max == 0 {
+		return fmt.Errorf("was expected to never be called but was called %s", pluralizeTimes(called))
+	}
+
+	return fmt.Errorf("was expected to be called %s but was called %s", e, pluralizeTimes(called))
+}
+
+func (e *callExpectation) String() string {
+	switch {
+	case e.max < 0:
+		return "at least " + pluralizeTimes(e.min)
+	case e.min == e.max:
+		return pluralizeTimes(e.min)
+	case e.min == 0:
+		return "at most " + pluralizeTimes(e.max)
+	default:
+		return fmt.Sprintf("between %d and %s", e.min, pluralizeTimes(e.max))
+	}
+}
+
+func pluralizeTimes(n int) string {
+	if n == 1 {
+		return "1 time"
+	}
+	return fmt.Sprintf("%d times", n)
+}
@@ -0,0 +1,68 @@
+package httpfake
+
+import (
+	"testing"
+)
+
+func TestCallExpectation_Verify(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectation *callExpectation
+		called      int
+		expectedErr string
+	}{
+		{
+			name:        "exact calls should return no error when called the expected times",
+			expectation: &callExpectation{min: 2, max: 2},
+			called:      2,
+			expectedErr: "",
+		},
+		{
+			name:        "exact calls should return an error when called more times",
+			expectation: &callExpectation{min: 2, max: 2},
+			called:      3,
+			expectedErr: "was expected to be called 2 times but was called 3 times",
+		},
+		{
+			name:        "at least should return no error when called more times",
+			expectation: &callExpectation{min: 1, max: -1},
+			called:      5,
+			expectedErr: "",
+		},
+		{
+			name:        "at least should return an error when called fewer times",
+			expectation: &callExpectation{min: 2, max: -1},
+			called:      1,
+			expectedErr: "was expected to be called at least 2 times but was called 1 time",
+		},
+		{
+			name:        "at most should return no error when never called",
+			expectation: &callExpectation{min: 0, max: 1},
+			called:      0,
+			expectedErr: "",
+		},
+		{
+			name:        "at most should return an error when called more times",
+			expectation: &callExpectation{min: 0, max: 1},
+			called:      2,
+			expectedErr: "was expected to be called at most 1 time but was called 2 times",
+		},
+		{
+			name:        "never should return an error when called",
+			expectation: &callExpectation{min: 0, max: 0},
+			called:      1,
+			expectedErr: "was expected to never be called but was called 1 time",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.expectation.verify(tt.called)
+			if tt.expectedErr == "" && err != nil {
+				t.Errorf("verify() returned an unexpected error: %v", err)
+			}
+			if tt.expectedErr != "" && (err == nil || err.Error() != tt.expectedErr) {
+				t.Errorf("verify() returned an unexpected error: got %v want %s", err, tt.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package httpfake
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// Fault describes a network fault simulated by the fake server in place of a well-formed response
+type Fault int
+
+const (
+	// FaultEmptyResponse closes the connection without writing any response
+	FaultEmptyResponse Fault = iota + 1
+	// FaultConnectionReset closes the connection with a TCP reset (RST)
+	FaultConnectionReset
+	// FaultHang never responds and holds the connection until the client cancels the request
+	// or the fake server is closed, then closes the connection
+	FaultHang
+	// FaultHeadersOnly writes the response headers and closes the connection before writing the body
+	FaultHeadersOnly
+	// FaultTruncatedBody writes a Content-Length larger than the response body and closes the connection
+	// after writing the body
+	FaultTruncatedBody
+	// FaultMalformedResponse writes a response that is not valid HTTP and closes the connection
+	FaultMalformedResponse
+)
+
+// writeFault simulates the response fault by hijacking the connection
+func writeFault(w http.ResponseWriter, r *http.Request, res *Response) {
+	if res.fault == FaultHang {
+		<-r.Context().Done()
+		abort(w)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		printError("error simulating fault: the response writer does not support hijacking the connection")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		printError(fmt.Sprintf("error simulating fault: %v", err))
+		return
+	}
+	defer conn.Close() // nolint errcheck
+
+	switch res.fault {
+	case FaultConnectionReset:
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.SetLinger(0) // nolint errcheck
+		}
+	case FaultHeadersOnly:
+		contentLength := len(res.BodyBuffer)
+		if contentLength == 0 {
+			contentLength = 1
+		}
+		writeRawHeaders(buf, res, contentLength)
+	case FaultTruncatedBody:
+		writeRawHeaders(buf, res, 2*len(res.BodyBuffer)+1)
+		buf.Write(res.BodyBuffer) // nolint errcheck
+	case FaultMalformedResponse:
+		buf.WriteString("HTTP/1.1 ??? MALFORMED\r\nContent-Length: -1\r\n\r\n") // nolint errcheck
+	}
+
+	buf.Flush() // nolint errcheck
+}
+
+// writeRawHeaders writes the status line and the headers of the response straight to the connection
+func writeRawHeaders(buf *bufio.ReadWriter, res *Response, contentLength int) {
+	status := res.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	header := res.Header.Clone()
+	header.Set("Content-Length", strconv.Itoa(contentLength))
+
+	fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\n", status, http.StatusText(status))
+	header.Write(buf)       // nolint errcheck
+	buf.WriteString("\r\n") // nolint errcheck
+}
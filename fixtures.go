@@ -0,0 +1,280 @@
+package httpfake
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// stubDefinition is the declarative definition of a request handler loaded from a fixture file
+type stubDefinition struct {
+	Method      string         `json:"method"`
+	Path        string         `json:"path"`
+	PathPattern string         `json:"pathPattern"`
+	Match       stubMatch      `json:"match"`
+	Assert      stubAssert     `json:"assert"`
+	Response    *stubResponse  `json:"response"`
+	Responses   []stubResponse `json:"responses"`
+	Times       int            `json:"times"`
+	ExpectCalls *int           `json:"expectCalls"`
+}
+
+type stubMatch struct {
+	Headers    map[string]string `json:"headers"`
+	Query      map[string]string `json:"query"`
+	Body       *string           `json:"body"`
+	JSON       json.RawMessage   `json:"json"`
+	JSONSubset json.RawMessage   `json:"jsonSubset"`
+	Form       map[string]string `json:"form"`
+}
+
+type stubAssert struct {
+	Headers      []string          `json:"headers"`
+	HeaderValues map[string]string `json:"headerValues"`
+	Queries      []string          `json:"queries"`
+	QueryValues  map[string]string `json:"queryValues"`
+	Body         *string           `json:"body"`
+}
+
+type stubResponse struct {
+	Status    int               `json:"status"`
+	Headers   map[string]string `json:"headers"`
+	Body      *string           `json:"body"`
+	JSONBody  json.RawMessage   `json:"jsonBody"`
+	BodyFile  string            `json:"bodyFile"`
+	Delay     string            `json:"delay"`
+	Templated bool              `json:"templated"`
+}
+
+// LoadFile registers the request handlers defined in a JSON fixture file.
+// The file holds a single definition, a list of definitions or an object with the list under "stubs".
+// Each definition sets the method, the path (or a regular expression as "pathPattern"), the matchers,
+// the assertions and the response, whose body can be read from a file relative to the fixture file.
+// The handlers are not expected to be called by Close unless the definition sets "expectCalls".
+// Example:
+//     {
+//       "method": "GET",
+//       "path": "/users/{id}",
+//       "match": {"headers": {"Accept": "application/json"}},
+//       "response": {"status": 200, "headers": {"Content-Type": "application/json"}, "bodyFile": "user.json"}
+//     }
+func (f *HTTPFake) LoadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	return f.loadStubs(path, data, func(name string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	})
+}
+
+// loadStubs decodes the definitions of a fixture file and registers them as request handlers.
+// The body files are read with the given function.
+func (f *HTTPFake) loadStubs(name string, data []byte, readFile func(name string) ([]byte, error)) error {
+	handlers, err := stubHandlers(name, data, readFile)
+	if err != nil {
+		return err
+	}
+
+	f.RequestHandlers = append(f.RequestHandlers, handlers...)
+	return nil
+}
+
+// stubHandlers decodes the definitions of a fixture file and creates their request handlers
+// without registering them, so an invalid fixture file does not leave the fake half configured
+func stubHandlers(name string, data []byte, readFile func(name string) ([]byte, error)) ([]*Request, error) {
+	defs, err := decodeStubs(name, data)
+	if err != nil {
+		return nil, fmt.Errorf("loading fixture %s: %w", name, err)
+	}
+
+	handlers := make([]*Request, 0, len(defs))
+	for i, def := range defs {
+		rh, err := def.handler(readFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading fixture %s: stub %d: %w", name, i, err)
+		}
+		handlers = append(handlers, rh)
+	}
+	return handlers, nil
+}
+
+// decodeStubs decodes the definitions from a JSON file
+func decodeStubs(name string, data []byte) ([]stubDefinition, error) {
+	if ext := strings.ToLower(filepath.Ext(name)); ext == ".yaml" || ext == ".yml" {
+		return nil, errors.New("YAML fixture files are not supported, convert them to JSON")
+	}
+
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj, ok := doc.(map[string]interface{}); ok {
+		if stubs, ok := obj["stubs"]; ok {
+			doc = stubs
+		} else {
+			doc = []interface{}{obj}
+		}
+	}
+	if _, ok := doc.([]interface{}); !ok {
+		return nil, errors.New("expected a stub definition or a list of stub definitions")
+	}
+
+	// the decoded document is converted back to JSON
+	// so the single definitions and the lists share the same definition structs
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []stubDefinition
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&defs); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// handler creates the request handler set by the definition
+func (def *stubDefinition) handler(readFile func(name string) ([]byte, error)) (*Request, error) {
+	if def.Method == "" {
+		return nil, errors.New("missing method")
+	}
+
+	rh := NewRequest()
+	switch {
+	case def.PathPattern != "":
+		pattern, err := regexp.Compile(def.PathPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path pattern: %w", err)
+		}
+		rh.methodMatching(def.Method, pattern, false)
+	case def.Path != "":
+		rh.method(def.Method, def.Path)
+	default:
+		return nil, errors.New("missing path or pathPattern")
+	}
+
+	def.Match.apply(rh)
+	def.Assert.apply(rh)
+
+	if def.Times > 0 {
+		rh.Times(def.Times)
+	}
+	// the loaded handlers are not expected to be called unless the definition says so
+	rh.ExpectAtLeast(0)
+	if def.ExpectCalls != nil {
+		rh.ExpectCalls(*def.ExpectCalls)
+	}
+
+	responses := def.Responses
+	if def.Response != nil {
+		responses = append([]stubResponse{*def.Response}, responses...)
+	}
+	for i, res := range responses {
+		if i > 0 {
+			rh.Response.Then()
+		}
+		if err := res.apply(rh.Response, readFile); err != nil {
+			return nil, fmt.Errorf("response %d: %w", i, err)
+		}
+	}
+
+	return rh, nil
+}
+
+// apply sets the matchers of the definition, in the order of their keys so the handler is described the same way
+// every time it is loaded
+func (m stubMatch) apply(rh *Request) {
+	for _, key := range sortedKeys(m.Headers) {
+		rh.MatchHeader(key, m.Headers[key])
+	}
+	for _, key := range sortedKeys(m.Query) {
+		rh.MatchQuery(key, m.Query[key])
+	}
+	if m.Body != nil {
+		rh.MatchBody([]byte(*m.Body))
+	}
+	if m.JSON != nil {
+		rh.MatchJSON(m.JSON)
+	}
+	if m.JSONSubset != nil {
+		rh.MatchJSONSubset(m.JSONSubset)
+	}
+	for _, key := range sortedKeys(m.Form) {
+		rh.MatchFormValue(key, m.Form[key])
+	}
+}
+
+func (a stubAssert) apply(rh *Request) {
+	if len(a.Headers) > 0 {
+		rh.AssertHeaders(a.Headers...)
+	}
+	for _, key := range sortedKeys(a.HeaderValues) {
+		rh.AssertHeaderValue(key, a.HeaderValues[key])
+	}
+	if len(a.Queries) > 0 {
+		rh.AssertQueries(a.Queries...)
+	}
+	for _, key := range sortedKeys(a.QueryValues) {
+		rh.AssertQueryValue(key, a.QueryValues[key])
+	}
+	if a.Body != nil {
+		rh.AssertBody([]byte(*a.Body))
+	}
+}
+
+func (s stubResponse) apply(res *Response, readFile func(name string) ([]byte, error)) error {
+	status := s.Status
+	if status == 0 {
+		status = 200
+	}
+	res.Status(status)
+
+	for _, key := range sortedKeys(s.Headers) {
+		res.SetHeader(key, s.Headers[key])
+	}
+
+	switch {
+	case s.Body != nil:
+		res.BodyString(*s.Body)
+	case s.JSONBody != nil:
+		if res.Header.Get("Content-Type") == "" {
+			res.SetHeader("Content-Type", "application/json")
+		}
+		res.Body(s.JSONBody)
+	case s.BodyFile != "":
+		body, err := readFile(s.BodyFile)
+		if err != nil {
+			return err
+		}
+		res.Body(body)
+	}
+
+	if s.Delay != "" {
+		delay, err := time.ParseDuration(s.Delay)
+		if err != nil {
+			return fmt.Errorf("invalid delay: %w", err)
+		}
+		res.Delay(delay)
+	}
+
+	if s.Templated {
+		if _, err := parseResponseTemplates(res.Header, res.BodyBuffer); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		res.Templated()
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+//go:build go1.16
+// +build go1.16
+
+package httpfake
+
+import (
+	"io/fs"
+	"path"
+)
+
+// LoadFS registers the request handlers defined in the fixture files of fsys matching the glob pattern,
+// as LoadFile does for a single file. The body files are read from fsys relative to each fixture file.
+// No handler is registered if any of the files is invalid.
+// Example:
+//     //go:embed fixtures
+//     var fixtures embed.FS
+//
+//     fakeService.LoadFS(fixtures, "fixtures/*.json")
+func (f *HTTPFake) LoadFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+
+	// the handlers of every file are created before registering any of them
+	// so an invalid fixture file does not leave the fake half configured
+	var handlers []*Request
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		dir := path.Dir(name)
+		fileHandlers, err := stubHandlers(name, data, func(file string) ([]byte, error) {
+			return fs.ReadFile(fsys, path.Join(dir, file))
+		})
+		if err != nil {
+			return err
+		}
+		handlers = append(handlers, fileHandlers...)
+	}
+
+	f.RequestHandlers = append(f.RequestHandlers, handlers...)
+	return nil
+}
+
+// LoadWireMockFS registers the request handlers defined by the WireMock stub mappings in the "mappings"
+// folder of the given directory of fsys, as LoadWireMock does. The body files are read from the "__files" folder.
+func (f *HTTPFake) LoadWireMockFS(fsys fs.FS, dir string) error {
+	names, err := fs.Glob(fsys, path.Join(dir, "mappings", "*.json"))
+	if err != nil {
+		return err
+	}
+
+	readFile := func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+	return f.loadWireMock(names, readFile, func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, path.Join(dir, "__files", name))
+	})
+}
@@ -0,0 +1,32 @@
+package httpfake
+
+import (
+	"testing"
+)
+
+func TestStubDefinitionHandler(t *testing.T) {
+	defs, err := decodeStubs("users.json", []byte(`{
+		"method": "GET",
+		"path": "/users",
+		"match": {
+			"headers": {"X-Tenant": "dreamers", "Accept": "application/json", "Authorization": "Bearer token"},
+			"query": {"page": "1", "limit": "10"}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the matchers are set in the order of their keys every time
+	expected := "[GET: /users] with header Accept=application/json, header Authorization=Bearer token, " +
+		"header X-Tenant=dreamers, query limit=10, query page=1"
+	for i := 0; i < 10; i++ {
+		rh, err := defs[0].handler(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc := rh.describe(); desc != expected {
+			t.Fatalf("describe() = %q, expected %q", desc, expected)
+		}
+	}
+}
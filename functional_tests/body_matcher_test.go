@@ -0,0 +1,111 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestBodyMatchers tests a fake server handling POST requests
+// for the same path selected by the request body
+func TestBodyMatchers(t *testing.T) {
+	fakeService := httpfake.New(httpfake.WithTesting(t))
+	defer fakeService.Close()
+
+	// register the handlers for our fake service
+	fakeService.NewHandler().
+		Post("/rpc").
+		MatchJSONSubset([]byte(`{"method": "users.get"}`)).
+		AssertBody([]byte(`{"jsonrpc": "2.0", "method": "users.get", "id": 1}`)).
+		Reply(200).
+		BodyString(`{"jsonrpc": "2.0", "result": [{"username": "dreamer"}], "id": 1}`)
+
+	fakeService.NewHandler().
+		Post("/rpc").
+		MatchJSONSubset([]byte(`{"method": "users.delete"}`)).
+		Handle(func(w http.ResponseWriter, r *http.Request, rh *httpfake.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			w.WriteHeader(200)
+			w.Write(body) // nolint
+		})
+
+	fakeService.NewHandler().
+		Post("/login").
+		MatchFormValue("username", "dreamer").
+		Reply(204)
+
+	testCases := []struct {
+		name           string
+		path           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "users.get",
+			path:           "/rpc",
+			contentType:    "application/json",
+			body:           `{"jsonrpc": "2.0", "method": "users.get", "id": 1}`,
+			expectedStatus: 200,
+			expectedBody:   `{"jsonrpc": "2.0", "result": [{"username": "dreamer"}], "id": 1}`,
+		},
+		{
+			name:           "users.delete",
+			path:           "/rpc",
+			contentType:    "application/json",
+			body:           `{"jsonrpc": "2.0", "method": "users.delete", "id": 2}`,
+			expectedStatus: 200,
+			expectedBody:   `{"jsonrpc": "2.0", "method": "users.delete", "id": 2}`,
+		},
+		{
+			name:           "users.update",
+			path:           "/rpc",
+			contentType:    "application/json",
+			body:           `{"jsonrpc": "2.0", "method": "users.update", "id": 3}`,
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+		{
+			name:           "login",
+			path:           "/login",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "username=dreamer&password=secret",
+			expectedStatus: 204,
+			expectedBody:   "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", fakeService.ResolveURL(tc.path), strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", tc.contentType)
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close() // nolint errcheck
+
+			// Check the status code is what we expect
+			if status := res.StatusCode; status != tc.expectedStatus {
+				t.Errorf("request returned wrong status code: got %v want %v",
+					status, tc.expectedStatus)
+			}
+
+			// Check the response body is what we expect
+			body, _ := ioutil.ReadAll(res.Body)
+			if bodyString := string(body); bodyString != tc.expectedBody {
+				t.Errorf("request returned unexpected body: got %v want %v",
+					bodyString, tc.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestCustomMatcher tests a fake server handling GET requests
+// for the same path selected by custom matchers
+func TestCustomMatcher(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Server.Close()
+
+	// register the handlers for our fake service
+	fakeService.NewHandler().
+		Get("/users").
+		Match(httpfake.MatcherFunc(func(r *http.Request) bool {
+			return r.Host == "fake.example.com"
+		})).
+		Reply(200).
+		BodyString(`[{"username": "example"}]`)
+
+	fakeService.NewHandler().
+		Get("/users").
+		Match(httpfake.MatcherFunc(func(r *http.Request) bool {
+			return r.Host != "fake.example.com"
+		})).
+		Reply(200).
+		BodyString(`[{"username": "dreamer"}]`)
+
+	testCases := []struct {
+		host         string
+		expectedBody string
+	}{
+		{
+			host:         "fake.example.com",
+			expectedBody: `[{"username": "example"}]`,
+		},
+		{
+			host:         "",
+			expectedBody: `[{"username": "dreamer"}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.host, func(t *testing.T) {
+			req, err := http.NewRequest("GET", fakeService.ResolveURL("/users"), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.host != "" {
+				req.Host = tc.host
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close() // nolint errcheck
+
+			// Check the status code is what we expect
+			if status := res.StatusCode; status != 200 {
+				t.Errorf("request returned wrong status code: got %v want %v",
+					status, 200)
+			}
+
+			// Check the response body is what we expect
+			body, _ := ioutil.ReadAll(res.Body)
+			if bodyString := string(body); bodyString != tc.expectedBody {
+				t.Errorf("request returned unexpected body: got %v want %v",
+					bodyString, tc.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestExpectCalls tests a fake server verifying
+// how many times each handler was called when it is closed
+func TestExpectCalls(t *testing.T) {
+	fakeService := httpfake.New(httpfake.WithTesting(t))
+	defer fakeService.Close()
+
+	// register the handlers for our fake service
+	fakeService.NewHandler().
+		Get("/users").
+		ExpectCalls(2).
+		Reply(200)
+
+	fakeService.NewHandler().
+		Post("/users").
+		ExpectAtLeast(1).
+		Reply(201)
+
+	fakeService.NewHandler().
+		Put("/users").
+		ExpectAtMost(1).
+		Reply(200)
+
+	fakeService.NewHandler().
+		Delete("/users").
+		ExpectNever().
+		Reply(204)
+
+	for _, method := range []string{"GET", "GET", "POST", "POST", "POST"} {
+		req, err := http.NewRequest(method, fakeService.ResolveURL("/users"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close() // nolint errcheck
+	}
+}
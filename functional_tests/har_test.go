@@ -0,0 +1,217 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestHAR tests a fake server exporting the received requests as a HAR file
+// and another fake server replaying them from the HAR file
+func TestHAR(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+	path := filepath.Join(dir, "users.har")
+
+	testCases := []replayTestCase{
+		{method: "GET", path: "/users?page=1", expectedStatus: 200, expectedBody: `[{"username": "dreamer"}]`},
+		{method: "POST", path: "/users", body: `{"username": "sleeper"}`, expectedStatus: 201, expectedBody: `{"id": 2}`},
+		{method: "GET", path: "/users/1", expectedStatus: 404, expectedBody: ""},
+	}
+
+	fakeService := httpfake.New()
+	fakeService.NewHandler().
+		Get("/users").
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`[{"username": "dreamer"}]`)
+	fakeService.NewHandler().
+		Post("/users").
+		Reply(201).
+		BodyString(`{"id": 2}`)
+	sendRequests(t, fakeService, testCases)
+	fakeService.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fakeService.WriteHAR(file); err != nil {
+		t.Fatal(err)
+	}
+	file.Close() // nolint errcheck
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					URL string `json:"url"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the HAR entries are what we expect
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != len(testCases) {
+		t.Fatalf("HAR has unexpected entries: got version %v with %v entries want version 1.2 with %v entries",
+			har.Log.Version, len(har.Log.Entries), len(testCases))
+	}
+	for i, tc := range testCases {
+		entry := har.Log.Entries[i]
+		if url := fakeService.ResolveURL(tc.path); entry.Request.URL != url {
+			t.Errorf("HAR entry has unexpected URL: got %v want %v", entry.Request.URL, url)
+		}
+		if entry.Response.Status != tc.expectedStatus || entry.Response.Content.Text != tc.expectedBody {
+			t.Errorf("HAR entry has unexpected response: got %v %v want %v %v",
+				entry.Response.Status, entry.Response.Content.Text, tc.expectedStatus, tc.expectedBody)
+		}
+	}
+
+	// replay the requests from the HAR file
+	replaying := httpfake.New()
+	defer replaying.Close()
+	if err := replaying.LoadHAR(path); err != nil {
+		t.Fatal(err)
+	}
+	sendRequests(t, replaying, testCases)
+}
+
+const browserHAR = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "WebInspector", "version": "537.36"},
+    "entries": [
+      {
+        "startedDateTime": "2006-01-02T15:04:05.000Z",
+        "time": 12,
+        "request": {
+          "method": "GET",
+          "url": "https://example.com/",
+          "httpVersion": "http/2.0",
+          "headers": [{"name": ":authority", "value": "example.com"}, {"name": "accept", "value": "text/html"}],
+          "queryString": [],
+          "cookies": [],
+          "headersSize": -1,
+          "bodySize": 0
+        },
+        "response": {
+          "status": 200,
+          "statusText": "",
+          "httpVersion": "http/2.0",
+          "headers": [{"name": "content-type", "value": "text/html"}],
+          "cookies": [],
+          "content": {"size": 13, "mimeType": "text/html", "text": "<h1>home</h1>"},
+          "redirectURL": "",
+          "headersSize": -1,
+          "bodySize": -1
+        },
+        "cache": {},
+        "timings": {"send": 0, "wait": 10, "receive": 2}
+      },
+      {
+        "startedDateTime": "2006-01-02T15:04:05.100Z",
+        "time": 8,
+        "request": {
+          "method": "GET",
+          "url": "https://example.com/app.js",
+          "httpVersion": "http/2.0",
+          "headers": [],
+          "queryString": [],
+          "cookies": [],
+          "headersSize": -1,
+          "bodySize": 0
+        },
+        "response": {
+          "status": 200,
+          "statusText": "",
+          "httpVersion": "http/2.0",
+          "headers": [],
+          "cookies": [],
+          "content": {"size": 15, "mimeType": "text/javascript", "text": "Y29uc29sZS5sb2coMSk=", "encoding": "base64"},
+          "redirectURL": "",
+          "headersSize": -1,
+          "bodySize": -1
+        },
+        "cache": {},
+        "timings": {"send": 0, "wait": 6, "receive": 2}
+      },
+      {
+        "startedDateTime": "2006-01-02T15:04:05.200Z",
+        "time": 4,
+        "request": {
+          "method": "GET",
+          "url": "https://example.com/favicon.ico",
+          "httpVersion": "http/2.0",
+          "headers": [],
+          "queryString": [],
+          "cookies": [],
+          "headersSize": -1,
+          "bodySize": 0
+        },
+        "response": {
+          "status": 404,
+          "statusText": "",
+          "httpVersion": "http/2.0",
+          "headers": [],
+          "cookies": [],
+          "content": {"size": 0, "mimeType": "x-unknown"},
+          "redirectURL": "",
+          "headersSize": -1,
+          "bodySize": -1
+        },
+        "cache": {},
+        "timings": {"send": 0, "wait": 3, "receive": 1}
+      }
+    ]
+  }
+}`
+
+// TestLoadHAR tests a fake server replaying the entries of a HAR file exported by a browser
+func TestLoadHAR(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+
+	path := filepath.Join(dir, "example.har")
+	if err := ioutil.WriteFile(path, []byte(browserHAR), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeService := httpfake.New(httpfake.WithTesting(t))
+	defer fakeService.Close()
+
+	if err := fakeService.LoadHAR(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// the entry for /favicon.ico is not requested and does not fail the test
+	sendRequests(t, fakeService, []replayTestCase{
+		{method: "GET", path: "/", expectedStatus: 200, expectedBody: "<h1>home</h1>"},
+		{method: "GET", path: "/app.js", expectedStatus: 200, expectedBody: "console.log(1)"},
+	})
+}
@@ -0,0 +1,104 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestHeaderAndQueryMatchers tests a fake server handling GET requests
+// for the same path selected by header and query values
+func TestHeaderAndQueryMatchers(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Server.Close()
+
+	// register the handlers for our fake service
+	fakeService.NewHandler().
+		Get("/users").
+		MatchHeader("Accept", "application/xml").
+		Reply(200).
+		BodyString(`<users><user>dreamer</user></users>`)
+
+	fakeService.NewHandler().
+		Get("/users").
+		MatchHeader("Accept", "application/json").
+		MatchQuery("api-version", "1").
+		Reply(200).
+		BodyString(`[{"username": "dreamer"}]`)
+
+	fakeService.NewHandler().
+		Get("/users").
+		MatchHeader("Accept", "application/json").
+		MatchQuery("api-version", "2").
+		Reply(200).
+		BodyString(`{"users": [{"username": "dreamer"}]}`)
+
+	testCases := []struct {
+		name           string
+		path           string
+		accept         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "xml",
+			path:           "/users",
+			accept:         "application/xml",
+			expectedStatus: 200,
+			expectedBody:   `<users><user>dreamer</user></users>`,
+		},
+		{
+			name:           "json v1",
+			path:           "/users?api-version=1",
+			accept:         "application/json",
+			expectedStatus: 200,
+			expectedBody:   `[{"username": "dreamer"}]`,
+		},
+		{
+			name:           "json v2",
+			path:           "/users?api-version=2",
+			accept:         "application/json",
+			expectedStatus: 200,
+			expectedBody:   `{"users": [{"username": "dreamer"}]}`,
+		},
+		{
+			name:           "json without version",
+			path:           "/users",
+			accept:         "application/json",
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", fakeService.ResolveURL(tc.path), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", tc.accept)
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close() // nolint errcheck
+
+			// Check the status code is what we expect
+			if status := res.StatusCode; status != tc.expectedStatus {
+				t.Errorf("request returned wrong status code: got %v want %v",
+					status, tc.expectedStatus)
+			}
+
+			// Check the response body is what we expect
+			body, _ := ioutil.ReadAll(res.Body)
+			if bodyString := string(body); bodyString != tc.expectedBody {
+				t.Errorf("request returned unexpected body: got %v want %v",
+					bodyString, tc.expectedBody)
+			}
+		})
+	}
+}
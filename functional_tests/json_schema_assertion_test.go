@@ -0,0 +1,58 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestJSONSchemaAssertion tests a fake server asserting
+// the body of a POST request against a JSON schema
+func TestJSONSchemaAssertion(t *testing.T) {
+	fakeService := httpfake.New(httpfake.WithTesting(t))
+	defer fakeService.Close()
+
+	// register a handler for our fake service
+	fakeService.NewHandler().
+		Post("/users").
+		AssertJSONSchema([]byte(`{
+			"type": "object",
+			"required": ["id", "username", "createdAt"],
+			"properties": {
+				"id": {"type": "string", "format": "uuid"},
+				"username": {"type": "string", "minLength": 1},
+				"createdAt": {"type": "string", "format": "date-time"}
+			}
+		}`)).
+		Reply(201).
+		BodyString(`{"username": "dreamer"}`)
+
+	sendBody := bytes.NewBuffer([]byte(`{
+		"id": "0b7b2a34-5bd4-4a8e-9b4e-c6b6e2a1c5f3",
+		"username": "dreamer",
+		"createdAt": "2006-01-02T15:04:05Z"
+	}`))
+	res, err := http.Post(fakeService.ResolveURL("/users"), "application/json", sendBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close() // nolint errcheck
+
+	// Check the status code is what we expect
+	if status := res.StatusCode; status != 201 {
+		t.Errorf("request returned wrong status code: got %v want %v",
+			status, 201)
+	}
+
+	// Check the response body is what we expect
+	expected := `{"username": "dreamer"}`
+	body, _ := ioutil.ReadAll(res.Body)
+	if bodyString := string(body); bodyString != expected {
+		t.Errorf("request returned unexpected body: got %v want %v",
+			bodyString, expected)
+	}
+}
@@ -0,0 +1,218 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+const usersFixture = `{
+  "stubs": [
+    {
+      "method": "GET",
+      "path": "/users/{id}",
+      "match": {"headers": {"Accept": "application/json"}},
+      "response": {
+        "status": 200,
+        "headers": {"Content-Type": "application/json"},
+        "bodyFile": "user.json"
+      }
+    },
+    {
+      "method": "POST",
+      "path": "/users",
+      "assert": {"headerValues": {"Content-Type": "application/json"}},
+      "responses": [
+        {"status": 503},
+        {"status": 201, "jsonBody": {"id": 1}}
+      ]
+    },
+    {
+      "method": "PATCH",
+      "path": "/users/{id}",
+      "response": {"status": 204}
+    }
+  ]
+}`
+
+const ordersFixture = `[
+  {
+    "method": "DELETE",
+    "pathPattern": "^/orders/\\d+$",
+    "response": {"status": 204}
+  },
+  {
+    "method": "GET",
+    "path": "/",
+    "response": {"status": 200, "body": "home"}
+  }
+]`
+
+// TestLoadFile tests a fake server handling requests
+// with handlers loaded from JSON fixture files
+func TestLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+
+	files := map[string]string{
+		"users.json":  usersFixture,
+		"user.json":   `{"id": 1, "username": "dreamer"}`,
+		"orders.json": ordersFixture,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fakeService := httpfake.New(httpfake.WithTesting(t))
+	defer fakeService.Close()
+
+	// register the handlers defined in the fixture files
+	for _, name := range []string{"users.json", "orders.json"} {
+		if err := fakeService.LoadFile(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		method         string
+		path           string
+		header         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			method:         "GET",
+			path:           "/users/1",
+			header:         "Accept",
+			expectedStatus: 200,
+			expectedBody:   `{"id": 1, "username": "dreamer"}`,
+		},
+		{
+			method:         "GET",
+			path:           "/users/1",
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+		{
+			method:         "POST",
+			path:           "/users",
+			header:         "Content-Type",
+			body:           `{"username": "dreamer"}`,
+			expectedStatus: 503,
+			expectedBody:   "",
+		},
+		{
+			method:         "POST",
+			path:           "/users",
+			header:         "Content-Type",
+			body:           `{"username": "dreamer"}`,
+			expectedStatus: 201,
+			expectedBody:   `{"id":1}`,
+		},
+		{
+			method:         "DELETE",
+			path:           "/orders/10",
+			expectedStatus: 204,
+			expectedBody:   "",
+		},
+		{
+			method:         "GET",
+			path:           "/",
+			expectedStatus: 200,
+			expectedBody:   "home",
+		},
+	}
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(tc.method, fakeService.ResolveURL(tc.path), strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.header != "" {
+			req.Header.Set(tc.header, "application/json")
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the status code is what we expect
+		if status := res.StatusCode; status != tc.expectedStatus {
+			t.Errorf("request returned wrong status code: got %v want %v",
+				status, tc.expectedStatus)
+		}
+
+		// Check the response body is what we expect
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close() // nolint errcheck
+		if bodyString := string(body); bodyString != tc.expectedBody {
+			t.Errorf("request returned unexpected body: got %v want %v",
+				bodyString, tc.expectedBody)
+		}
+	}
+}
+
+// TestLoadFileInvalid tests loading a fixture file with an invalid definition
+func TestLoadFileInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+
+	path := filepath.Join(dir, "invalid.json")
+	fixture := `[{"method": "GET", "path": "/users"}, {"method": "GET", "response": {"status": 200}}]`
+	if err := ioutil.WriteFile(path, []byte(fixture), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// Check the error is what we expect
+	err = fakeService.LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), "stub 1: missing path or pathPattern") {
+		t.Errorf("load file returned unexpected error: got %v", err)
+	}
+
+	// Check no handler is registered from the invalid file
+	if len(fakeService.RequestHandlers) != 0 {
+		t.Errorf("load file registered unexpected handlers: got %v want 0", len(fakeService.RequestHandlers))
+	}
+}
+
+// TestLoadFileYAML tests loading a YAML fixture file, which is not supported
+func TestLoadFileYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+
+	path := filepath.Join(dir, "users.yaml")
+	if err := ioutil.WriteFile(path, []byte("method: GET\npath: /users\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// Check the error is what we expect
+	err = fakeService.LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), "YAML fixture files are not supported") {
+		t.Errorf("load file returned unexpected error: got %v", err)
+	}
+}
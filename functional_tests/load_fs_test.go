@@ -0,0 +1,84 @@
+//go:build go1.16
+// +build go1.16
+
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestLoadFS tests a fake server handling requests
+// with handlers loaded from the fixture files of a file system
+func TestLoadFS(t *testing.T) {
+	fixtures := fstest.MapFS{
+		"fixtures/users.json": {Data: []byte(
+			`{"method": "GET", "path": "/users", "response": {"bodyFile": "bodies/users.json"}}`)},
+		"fixtures/bodies/users.json": {Data: []byte(`[{"username": "dreamer"}]`)},
+		"fixtures/health.json":       {Data: []byte(`{"method": "GET", "path": "/health", "response": {"body": "ok"}}`)},
+	}
+
+	fakeService := httpfake.New(httpfake.WithTesting(t))
+	defer fakeService.Close()
+
+	// register the handlers defined in the fixture files
+	if err := fakeService.LoadFS(fixtures, "fixtures/*.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		path         string
+		expectedBody string
+	}{
+		{path: "/users", expectedBody: `[{"username": "dreamer"}]`},
+		{path: "/health", expectedBody: "ok"},
+	}
+
+	for _, tc := range testCases {
+		res, err := http.Get(fakeService.ResolveURL(tc.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the status code is what we expect
+		if status := res.StatusCode; status != 200 {
+			t.Errorf("request returned wrong status code: got %v want %v",
+				status, 200)
+		}
+
+		// Check the response body is what we expect
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close() // nolint errcheck
+		if bodyString := string(body); bodyString != tc.expectedBody {
+			t.Errorf("request returned unexpected body: got %v want %v",
+				bodyString, tc.expectedBody)
+		}
+	}
+}
+
+// TestLoadFSInvalid tests a fake server
+// registering no handler when one of the fixture files is invalid
+func TestLoadFSInvalid(t *testing.T) {
+	fixtures := fstest.MapFS{
+		"fixtures/health.json": {Data: []byte(`{"method": "GET", "path": "/health", "response": {"body": "ok"}}`)},
+		"fixtures/users.json":  {Data: []byte(`{"path": "/users"}`)},
+	}
+
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// Check the invalid fixture file is reported
+	if err := fakeService.LoadFS(fixtures, "fixtures/*.json"); err == nil {
+		t.Fatal("loading the fixture files returned no error")
+	}
+
+	// Check the handlers of the valid fixture file are not registered either
+	if handlers := len(fakeService.RequestHandlers); handlers != 0 {
+		t.Errorf("loading the fixture files registered unexpected handlers: got %v want %v", handlers, 0)
+	}
+}
@@ -0,0 +1,124 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestMatchPrecedence tests a fake server handling GET requests
+// matched by several handlers with different precedences
+func TestMatchPrecedence(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Server.Close()
+
+	// register the handlers for our fake service
+	fakeService.NewHandler().
+		Get("/users/*").
+		Reply(200).
+		BodyString("wildcard")
+
+	fakeService.NewHandler().
+		Get("/users/{id}").
+		Reply(200).
+		BodyString("pattern")
+
+	fakeService.NewHandler().
+		Get("/users/{id}").
+		MatchHeader("Accept", "application/xml").
+		Reply(200).
+		BodyString("pattern with matcher")
+
+	fakeService.NewHandler().
+		Get("/users/1").
+		Reply(200).
+		BodyString("exact path")
+
+	fakeService.NewHandler().
+		Get("/users/1?active=true").
+		Reply(200).
+		BodyString("exact url")
+
+	fakeService.NewHandler().
+		Get("/users/2").
+		Reply(200).
+		BodyString("first exact path")
+
+	fakeService.NewHandler().
+		Get("/users/2").
+		Reply(200).
+		BodyString("last exact path")
+
+	testCases := []struct {
+		name         string
+		path         string
+		accept       string
+		expectedBody string
+	}{
+		{
+			name:         "exact url beats exact path",
+			path:         "/users/1?active=true",
+			expectedBody: "exact url",
+		},
+		{
+			name:         "exact path beats pattern",
+			path:         "/users/1?active=false",
+			expectedBody: "exact path",
+		},
+		{
+			name:         "pattern beats wildcard",
+			path:         "/users/3",
+			expectedBody: "pattern",
+		},
+		{
+			name:         "more matchers beats fewer",
+			path:         "/users/3",
+			accept:       "application/xml",
+			expectedBody: "pattern with matcher",
+		},
+		{
+			name:         "wildcard",
+			path:         "/users/3/posts",
+			expectedBody: "wildcard",
+		},
+		{
+			name:         "later registration overrides earlier",
+			path:         "/users/2",
+			expectedBody: "last exact path",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", fakeService.ResolveURL(tc.path), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close() // nolint errcheck
+
+			// Check the status code is what we expect
+			if status := res.StatusCode; status != 200 {
+				t.Errorf("request returned wrong status code: got %v want %v",
+					status, 200)
+			}
+
+			// Check the response body is what we expect
+			body, _ := ioutil.ReadAll(res.Body)
+			if bodyString := string(body); bodyString != tc.expectedBody {
+				t.Errorf("request returned unexpected body: got %v want %v",
+					bodyString, tc.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+const usersOpenAPI = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Users", "version": "1.0"},
+  "servers": [
+    {"url": "https://api.example.com/{version}", "variables": {"version": {"default": "v1"}}}
+  ],
+  "paths": {
+    "/users": {
+      "get": {
+        "responses": {
+          "200": {
+            "description": "the users",
+            "content": {
+              "application/json": {
+                "schema": {"type": "array", "items": {"$ref": "#/components/schemas/User"}}
+              }
+            }
+          }
+        }
+      },
+      "post": {
+        "responses": {
+          "201": {
+            "description": "the created user",
+            "content": {
+              "application/json": {
+                "examples": {"dreamer": {"value": {"id": 1, "username": "dreamer"}}}
+              }
+            }
+          },
+          "400": {"description": "invalid user"}
+        }
+      }
+    },
+    "/users/{id}": {
+      "get": {
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+        "responses": {
+          "200": {
+            "description": "the user",
+            "content": {"application/json": {"example": {"id": 1, "username": "dreamer"}}}
+          }
+        }
+      },
+      "delete": {
+        "responses": {"204": {"description": "the user was deleted"}}
+      }
+    },
+    "/users/{id}/avatar.{format}": {
+      "get": {
+        "responses": {
+          "default": {
+            "description": "the avatar",
+            "content": {"text/plain": {"example": "avatar"}}
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "User": {
+        "type": "object",
+        "required": ["id", "username"],
+        "properties": {
+          "id": {"type": "integer", "minimum": 1},
+          "username": {"type": "string", "example": "sleeper"},
+          "email": {"type": "string", "format": "email"}
+        }
+      }
+    }
+  }
+}`
+
+// TestOpenAPI tests a fake server handling requests
+// with handlers generated from an OpenAPI document
+func TestOpenAPI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+
+	path := filepath.Join(dir, "users.json")
+	if err := ioutil.WriteFile(path, []byte(usersOpenAPI), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeService := httpfake.New(httpfake.WithTesting(t))
+	defer fakeService.Close()
+
+	// register the handlers generated from the OpenAPI document
+	if err := fakeService.LoadOpenAPI(path); err != nil {
+		t.Fatal(err)
+	}
+
+	sendRequests(t, fakeService, []replayTestCase{
+		{
+			method:         "GET",
+			path:           "/v1/users",
+			expectedStatus: 200,
+			expectedBody:   `[{"email":"user@example.com","id":1,"username":"sleeper"}]`,
+		},
+		{
+			method:         "POST",
+			path:           "/v1/users",
+			expectedStatus: 201,
+			expectedBody:   `{"id":1,"username":"dreamer"}`,
+		},
+		{
+			method:         "GET",
+			path:           "/v1/users/1",
+			expectedStatus: 200,
+			expectedBody:   `{"id":1,"username":"dreamer"}`,
+		},
+		{
+			method:         "DELETE",
+			path:           "/v1/users/1",
+			expectedStatus: 204,
+			expectedBody:   "",
+		},
+		{
+			method:         "GET",
+			path:           "/v1/users/1/avatar.png",
+			expectedStatus: 200,
+			expectedBody:   "avatar",
+		},
+		{
+			method:         "GET",
+			path:           "/users",
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+	})
+}
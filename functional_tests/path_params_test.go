@@ -0,0 +1,83 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestPathParams tests a fake server handling GET requests
+// for a route with path parameters and a wildcard
+func TestPathParams(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Server.Close()
+
+	// register a handler for our fake service
+	fakeService.NewHandler().
+		Get("/users/{id}/posts/{postID}").
+		Handle(func(w http.ResponseWriter, r *http.Request, rh *httpfake.Request) {
+			w.WriteHeader(200)
+			fmt.Fprintf(w, `{"user": "%s", "post": "%s"}`, rh.Param(r, "id"), rh.Param(r, "postID"))
+		})
+
+	fakeService.NewHandler().
+		Get("/files/*").
+		Handle(func(w http.ResponseWriter, r *http.Request, rh *httpfake.Request) {
+			w.WriteHeader(200)
+			fmt.Fprint(w, rh.Param(r, "*"))
+		})
+
+	testCases := []struct {
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			path:           "/users/1/posts/2",
+			expectedStatus: 200,
+			expectedBody:   `{"user": "1", "post": "2"}`,
+		},
+		{
+			path:           "/users/dreamer/posts/42?sort=asc",
+			expectedStatus: 200,
+			expectedBody:   `{"user": "dreamer", "post": "42"}`,
+		},
+		{
+			path:           "/users/1/posts",
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+		{
+			path:           "/files/docs/readme.md",
+			expectedStatus: 200,
+			expectedBody:   "docs/readme.md",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			res, err := http.Get(fakeService.ResolveURL(tc.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close() // nolint errcheck
+
+			// Check the status code is what we expect
+			if status := res.StatusCode; status != tc.expectedStatus {
+				t.Errorf("request returned wrong status code: got %v want %v",
+					status, tc.expectedStatus)
+			}
+
+			// Check the response body is what we expect
+			body, _ := ioutil.ReadAll(res.Body)
+			if bodyString := string(body); bodyString != tc.expectedBody {
+				t.Errorf("request returned unexpected body: got %v want %v",
+					bodyString, tc.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestRecordReplay tests a fake server recording the requests forwarded to an upstream server
+// and another fake server replaying them from the recorded cassette
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+	cassette := filepath.Join(dir, "users.json")
+
+	upstream := httpfake.New()
+	upstream.NewHandler().
+		Get("/users").
+		Reply(503).
+		Then().
+		Reply(200).
+		SetHeader("Content-Type", "application/json").
+		BodyString(`[{"username": "dreamer"}]`)
+	upstream.NewHandler().
+		Post("/users").
+		MatchBody([]byte(`{"username": "sleeper"}`)).
+		Reply(201).
+		BodyString(`{"id": 2}`)
+	upstream.NewHandler().
+		Get("/").
+		Reply(200).
+		BodyString("home")
+
+	testCases := []replayTestCase{
+		{method: "GET", path: "/users", expectedStatus: 503, expectedBody: ""},
+		{method: "GET", path: "/users", expectedStatus: 200, expectedBody: `[{"username": "dreamer"}]`},
+		{method: "POST", path: "/users", body: `{"username": "sleeper"}`, expectedStatus: 201, expectedBody: `{"id": 2}`},
+		{method: "GET", path: "/health", expectedStatus: 200, expectedBody: "ok"},
+		{method: "GET", path: "/", expectedStatus: 200, expectedBody: "home"},
+	}
+
+	// record the requests not handled by the fake server itself
+	recording := httpfake.New(httpfake.WithRecording(upstream.ResolveURL(""), cassette))
+	recording.NewHandler().
+		Get("/health").
+		Reply(200).
+		BodyString("ok")
+	sendRequests(t, recording, testCases)
+	sendRequests(t, recording, []replayTestCase{
+		{method: "DELETE", path: "/users", expectedStatus: 404, expectedBody: ""},
+	})
+	recording.Close()
+	upstream.Close()
+
+	// replay the recorded requests without the upstream server,
+	// the recorded requests which are not replayed do not fail the test
+	replaying := httpfake.New(httpfake.WithTesting(t))
+	defer replaying.Close()
+	if err := replaying.LoadCassette(cassette); err != nil {
+		t.Fatal(err)
+	}
+	replaying.NewHandler().
+		Get("/health").
+		Reply(200).
+		BodyString("ok")
+	sendRequests(t, replaying, testCases)
+
+	// Check the last recorded response is repeated
+	sendRequests(t, replaying, testCases[1:2])
+}
+
+// TestRecordBodyMatcher tests a fake server forwarding a request to the upstream server
+// after a custom matcher of another handler read the request body
+func TestRecordBodyMatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+	cassette := filepath.Join(dir, "users.json")
+
+	upstream := httpfake.New()
+	defer upstream.Close()
+	upstream.NewHandler().
+		Post("/users").
+		MatchBody([]byte(`{"username": "sleeper"}`)).
+		Reply(201).
+		BodyString(`{"id": 2}`)
+
+	// record the requests not handled by the fake server itself,
+	// the custom matcher reads the body of every request without restoring it
+	recording := httpfake.New(httpfake.WithRecording(upstream.ResolveURL(""), cassette))
+	recording.NewHandler().
+		Post("/users").
+		Match(httpfake.MatcherFunc(func(r *http.Request) bool {
+			body, _ := ioutil.ReadAll(r.Body)
+			return string(body) == `{"username": "dreamer"}`
+		})).
+		Reply(409)
+
+	testCases := []replayTestCase{
+		{method: "POST", path: "/users", body: `{"username": "sleeper"}`, expectedStatus: 201, expectedBody: `{"id": 2}`},
+	}
+	sendRequests(t, recording, testCases)
+	recording.Close()
+
+	// Check the forwarded request is replayed from the recorded cassette
+	replaying := httpfake.New(httpfake.WithTesting(t))
+	defer replaying.Close()
+	if err := replaying.LoadCassette(cassette); err != nil {
+		t.Fatal(err)
+	}
+	sendRequests(t, replaying, testCases)
+}
+
+type replayTestCase struct {
+	method         string
+	path           string
+	body           string
+	expectedStatus int
+	expectedBody   string
+}
+
+func sendRequests(t *testing.T, fakeService *httpfake.HTTPFake, testCases []replayTestCase) {
+	for _, tc := range testCases {
+		req, err := http.NewRequest(tc.method, fakeService.ResolveURL(tc.path), strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the status code is what we expect
+		if status := res.StatusCode; status != tc.expectedStatus {
+			t.Errorf("request returned wrong status code: got %v want %v",
+				status, tc.expectedStatus)
+		}
+
+		// Check the response body is what we expect
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close() // nolint errcheck
+		if bodyString := string(body); bodyString != tc.expectedBody {
+			t.Errorf("request returned unexpected body: got %v want %v",
+				bodyString, tc.expectedBody)
+		}
+	}
+}
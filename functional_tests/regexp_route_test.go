@@ -0,0 +1,89 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestRegexpRoute tests a fake server handling GET requests
+// for routes matched by regular expressions
+func TestRegexpRoute(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Server.Close()
+
+	// register a handler for our fake service
+	fakeService.NewHandler().
+		GetMatching(regexp.MustCompile(`^/v(?P<version>\d+)/users$`)).
+		Handle(func(w http.ResponseWriter, r *http.Request, rh *httpfake.Request) {
+			w.WriteHeader(200)
+			fmt.Fprintf(w, "users v%s", rh.Param(r, "version"))
+		})
+
+	fakeService.NewHandler().
+		URLMatching(http.MethodGet, regexp.MustCompile(`^/assets/[a-f0-9]{8}\.js\?v=(?P<hash>\w+)$`)).
+		Handle(func(w http.ResponseWriter, r *http.Request, rh *httpfake.Request) {
+			w.WriteHeader(200)
+			fmt.Fprintf(w, "asset %s", rh.Param(r, "hash"))
+		})
+
+	testCases := []struct {
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			path:           "/v2/users",
+			expectedStatus: 200,
+			expectedBody:   "users v2",
+		},
+		{
+			path:           "/v2/users?page=2",
+			expectedStatus: 200,
+			expectedBody:   "users v2",
+		},
+		{
+			path:           "/vx/users",
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+		{
+			path:           "/assets/0a1b2c3d.js?v=abc",
+			expectedStatus: 200,
+			expectedBody:   "asset abc",
+		},
+		{
+			path:           "/assets/0a1b2c3d.js",
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			res, err := http.Get(fakeService.ResolveURL(tc.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close() // nolint errcheck
+
+			// Check the status code is what we expect
+			if status := res.StatusCode; status != tc.expectedStatus {
+				t.Errorf("request returned wrong status code: got %v want %v",
+					status, tc.expectedStatus)
+			}
+
+			// Check the response body is what we expect
+			body, _ := ioutil.ReadAll(res.Body)
+			if bodyString := string(body); bodyString != tc.expectedBody {
+				t.Errorf("request returned unexpected body: got %v want %v",
+					bodyString, tc.expectedBody)
+			}
+		})
+	}
+}
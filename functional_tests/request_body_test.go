@@ -0,0 +1,59 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestRequestBody tests a fake server handling a POST request
+// with a body read by a matcher, the assertions and a custom responder
+func TestRequestBody(t *testing.T) {
+	fakeService := httpfake.New(httpfake.WithTesting(t))
+	defer fakeService.Close()
+
+	// register a handler for our fake service
+	fakeService.NewHandler().
+		Post("/users").
+		Match(httpfake.MatcherFunc(func(r *http.Request) bool {
+			body, _ := ioutil.ReadAll(r.Body)
+			return len(body) > 0
+		})).
+		AssertBody([]byte(`{"username": "dreamer"}`)).
+		AssertBody([]byte(`{"username": "dreamer"}`)).
+		Handle(func(w http.ResponseWriter, r *http.Request, rh *httpfake.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			if !bytes.Equal(body, rh.Body(r)) {
+				w.WriteHeader(500)
+				return
+			}
+
+			w.WriteHeader(201)
+			w.Write(body) // nolint
+		})
+
+	sendBody := bytes.NewBuffer([]byte(`{"username": "dreamer"}`))
+	res, err := http.Post(fakeService.ResolveURL("/users"), "application/json", sendBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close() // nolint errcheck
+
+	// Check the status code is what we expect
+	if status := res.StatusCode; status != 201 {
+		t.Errorf("request returned wrong status code: got %v want %v",
+			status, 201)
+	}
+
+	// Check the response body is what we expect
+	expected := `{"username": "dreamer"}`
+	body, _ := ioutil.ReadAll(res.Body)
+	if bodyString := string(body); bodyString != expected {
+		t.Errorf("request returned unexpected body: got %v want %v",
+			bodyString, expected)
+	}
+}
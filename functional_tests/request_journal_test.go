@@ -0,0 +1,72 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestRequestJournal tests a fake server recording
+// the requests it received for later inspection
+func TestRequestJournal(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Server.Close()
+
+	// register a handler for our fake service
+	handler := fakeService.NewHandler().
+		Post("/users")
+	handler.Reply(201)
+
+	sendBody := bytes.NewBuffer([]byte(`{"username": "dreamer"}`))
+	req, err := http.NewRequest(http.MethodPost, fakeService.ResolveURL("/users?notify=true"), sendBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close() // nolint errcheck
+
+	res, err = http.Get(fakeService.ResolveURL("/clients"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close() // nolint errcheck
+
+	// Check the calls recorded for the handler are what we expect
+	calls := handler.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("handler recorded unexpected number of calls: got %v want %v", len(calls), 1)
+	}
+	if body := string(calls[0].Body); body != `{"username": "dreamer"}` {
+		t.Errorf("handler recorded unexpected body: got %v want %v", body, `{"username": "dreamer"}`)
+	}
+	if value := calls[0].URL.Query().Get("notify"); value != "true" {
+		t.Errorf("handler recorded unexpected query value: got %v want %v", value, "true")
+	}
+	if value := calls[0].Header.Get("Content-Type"); value != "application/json" {
+		t.Errorf("handler recorded unexpected header value: got %v want %v", value, "application/json")
+	}
+	if calls[0].Time.IsZero() {
+		t.Error("handler recorded a call without time")
+	}
+
+	// Check the requests recorded by the server are what we expect
+	requests := fakeService.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("server recorded unexpected number of requests: got %v want %v", len(requests), 2)
+	}
+	if requests[0].Handler != handler {
+		t.Errorf("server recorded unexpected handler for %s", requests[0].URL)
+	}
+	if requests[1].Method != http.MethodGet || requests[1].URL.Path != "/clients" || requests[1].Handler != nil {
+		t.Errorf("server recorded unexpected request: got [%s: %s] handled by %v",
+			requests[1].Method, requests[1].URL, requests[1].Handler)
+	}
+}
@@ -0,0 +1,181 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestResponseDelay tests a fake server handling GET requests
+// with delayed responses
+func TestResponseDelay(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// register the handlers for our fake service
+	fakeService.NewHandler().
+		Get("/users").
+		Reply(200).
+		DelayBetween(50*time.Millisecond, 100*time.Millisecond).
+		BodyString(`[{"username": "dreamer"}]`)
+
+	fakeService.NewHandler().
+		Get("/clients").
+		Reply(200).
+		BodyDelay(50 * time.Millisecond).
+		BodyString(`[{"name": "dreamer"}]`)
+
+	fakeService.NewHandler().
+		Get("/slow").
+		Reply(200).
+		Delay(time.Minute)
+
+	start := time.Now()
+	res, err := http.Get(fakeService.ResolveURL("/users"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close() // nolint errcheck
+
+	// Check the response was delayed as we expect
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("request returned before the delay: got %v want at least %v", elapsed, 50*time.Millisecond)
+	}
+
+	start = time.Now()
+	res, err = http.Get(fakeService.ResolveURL("/clients"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	headersElapsed := time.Since(start)
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close() // nolint errcheck
+
+	// Check only the response body was delayed as we expect
+	if headersElapsed >= 50*time.Millisecond {
+		t.Errorf("request returned the headers after the body delay: got %v", headersElapsed)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("request returned the body before the delay: got %v want at least %v", elapsed, 50*time.Millisecond)
+	}
+	if bodyString := string(body); bodyString != `[{"name": "dreamer"}]` {
+		t.Errorf("request returned unexpected body: got %v want %v", bodyString, `[{"name": "dreamer"}]`)
+	}
+
+	// Check the client timeout is reached before the delay
+	client := &http.Client{
+		Timeout: 50 * time.Millisecond,
+	}
+	if _, err := client.Get(fakeService.ResolveURL("/slow")); err == nil {
+		t.Error("request returned no error before the delay")
+	}
+}
+
+// TestResponseDelayClientDisconnect tests a fake server
+// aborting a delayed response when the client disconnects
+func TestResponseDelayClientDisconnect(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// register the handler for our fake service
+	handler := fakeService.NewHandler().Get("/slow")
+	handler.Reply(200).Delay(time.Minute)
+
+	// cancel the request once the fake server is handling it
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		handler.WaitForCalls(context.Background(), 1) // nolint errcheck
+		cancel()
+	}()
+
+	req, err := http.NewRequest("GET", fakeService.ResolveURL("/slow"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.DefaultClient.Do(req.WithContext(ctx)); err == nil {
+		t.Fatal("request returned no error after being canceled")
+	}
+
+	// Check the delay is aborted on the server side, which lets the server close
+	// without canceling the requests as HTTPFake.Close does
+	closed := make(chan struct{})
+	go func() {
+		fakeService.Server.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("server kept delaying the response after the client disconnected")
+	}
+}
+
+// TestResponseDelayClose tests a fake server
+// aborting a delayed response when it is closed
+func TestResponseDelayClose(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func(res *httpfake.Response)
+	}{
+		{
+			name: "delay",
+			setup: func(res *httpfake.Response) {
+				res.Delay(time.Minute)
+			},
+		},
+		{
+			name: "body delay",
+			setup: func(res *httpfake.Response) {
+				res.BodyDelay(time.Minute)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeService := httpfake.New()
+
+			// register the handler for our fake service
+			handler := fakeService.NewHandler().Get("/slow")
+			tc.setup(handler.Reply(503).BodyString("unavailable"))
+
+			errs := make(chan error, 1)
+			go func() {
+				res, err := http.Get(fakeService.ResolveURL("/slow"))
+				if err == nil {
+					_, err = ioutil.ReadAll(res.Body)
+					res.Body.Close() // nolint errcheck
+				}
+				errs <- err
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := handler.WaitForCalls(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+
+			// Check the delay is aborted when the fake server is closed
+			start := time.Now()
+			fakeService.Close()
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("close waited for the delayed response: got %v want less than %v", elapsed, time.Second)
+			}
+
+			// Check the client gets an error instead of a response that was never configured
+			select {
+			case err := <-errs:
+				if err == nil {
+					t.Error("request returned no error after the fake server was closed")
+				}
+			case <-time.After(time.Second):
+				t.Error("request kept waiting after the fake server was closed")
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestResponseFault tests a fake server handling GET requests
+// with simulated network faults
+func TestResponseFault(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// checkEOF checks the connection was closed without any response
+	checkEOF := func(t *testing.T, res *http.Response, body []byte, err error) {
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("request returned unexpected error: got %v want %v", err, io.EOF)
+		}
+	}
+
+	// checkTruncated checks the response was sent with a body shorter than its Content-Length
+	checkTruncated := func(expectedBody string) func(t *testing.T, res *http.Response, body []byte, err error) {
+		return func(t *testing.T, res *http.Response, body []byte, err error) {
+			if res == nil {
+				t.Fatalf("request returned unexpected error: %v", err)
+			}
+			if res.StatusCode != 200 {
+				t.Errorf("request returned wrong status code: got %v want %v", res.StatusCode, 200)
+			}
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("reading the body returned unexpected error: got %v want %v", err, io.ErrUnexpectedEOF)
+			}
+			if string(body) != expectedBody {
+				t.Errorf("request returned unexpected body: got %v want %v", string(body), expectedBody)
+			}
+		}
+	}
+
+	testCases := []struct {
+		path    string
+		fault   httpfake.Fault
+		timeout time.Duration
+		check   func(t *testing.T, res *http.Response, body []byte, err error)
+	}{
+		{
+			path:  "/empty",
+			fault: httpfake.FaultEmptyResponse,
+			check: checkEOF,
+		},
+		{
+			path:  "/reset",
+			fault: httpfake.FaultConnectionReset,
+			check: func(t *testing.T, res *http.Response, body []byte, err error) {
+				// the reset may reach the client before or after it is done sending the request
+				if !errors.Is(err, syscall.ECONNRESET) && !errors.Is(err, io.EOF) {
+					t.Errorf("request returned unexpected error: got %v want %v", err, syscall.ECONNRESET)
+				}
+			},
+		},
+		{
+			path:    "/hang",
+			fault:   httpfake.FaultHang,
+			timeout: 100 * time.Millisecond,
+			check: func(t *testing.T, res *http.Response, body []byte, err error) {
+				var netErr net.Error
+				if !errors.As(err, &netErr) || !netErr.Timeout() {
+					t.Errorf("request returned unexpected error: got %v want a timeout", err)
+				}
+			},
+		},
+		{
+			path:  "/headers",
+			fault: httpfake.FaultHeadersOnly,
+			check: checkTruncated(""),
+		},
+		{
+			path:  "/truncated",
+			fault: httpfake.FaultTruncatedBody,
+			check: checkTruncated(`[{"username": "dreamer"}]`),
+		},
+		{
+			path:  "/malformed",
+			fault: httpfake.FaultMalformedResponse,
+			check: func(t *testing.T, res *http.Response, body []byte, err error) {
+				if err == nil || !strings.Contains(err.Error(), "malformed HTTP") {
+					t.Errorf("request returned unexpected error: got %v want a malformed HTTP response", err)
+				}
+			},
+		},
+	}
+
+	// register the handlers for our fake service
+	for _, tc := range testCases {
+		fakeService.NewHandler().
+			Get(tc.path).
+			Reply(200).
+			BodyString(`[{"username": "dreamer"}]`).
+			Fault(tc.fault)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			client := &http.Client{
+				Timeout: tc.timeout,
+				Transport: &http.Transport{
+					DisableKeepAlives: true,
+				},
+			}
+
+			var body []byte
+			res, err := client.Get(fakeService.ResolveURL(tc.path))
+			if err == nil {
+				body, err = ioutil.ReadAll(res.Body)
+				res.Body.Close() // nolint errcheck
+			}
+
+			// Check the request failed as we expect
+			tc.check(t, res, body, err)
+		})
+	}
+}
+
+// TestResponseFaultHangClose tests a fake server
+// closing the connection of a hanging request when it is closed
+func TestResponseFaultHangClose(t *testing.T) {
+	fakeService := httpfake.New()
+
+	// register the handler for our fake service
+	handler := fakeService.NewHandler().Get("/hang")
+	handler.Reply(200).Fault(httpfake.FaultHang)
+
+	errs := make(chan error, 1)
+	go func() {
+		res, err := http.Get(fakeService.ResolveURL("/hang"))
+		if err == nil {
+			_, err = ioutil.ReadAll(res.Body)
+			res.Body.Close() // nolint errcheck
+		}
+		errs <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := handler.WaitForCalls(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	fakeService.Close()
+
+	// Check the client gets an error instead of a successful empty response
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("request returned no error after the fake server was closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("request kept hanging after the fake server was closed")
+	}
+}
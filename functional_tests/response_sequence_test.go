@@ -0,0 +1,59 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestResponseSequence tests a fake server handling GET requests
+// with a different response for each call
+func TestResponseSequence(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Server.Close()
+
+	// register a handler for our fake service
+	fakeService.NewHandler().
+		Get("/users").
+		Reply(500).
+		BodyString(`{"error": "unavailable"}`).
+		Then().
+		Reply(503).
+		Then().
+		Reply(200).
+		BodyString(`[{"username": "dreamer"}]`)
+
+	testCases := []struct {
+		expectedStatus int
+		expectedBody   string
+	}{
+		{expectedStatus: 500, expectedBody: `{"error": "unavailable"}`},
+		{expectedStatus: 503, expectedBody: ""},
+		{expectedStatus: 200, expectedBody: `[{"username": "dreamer"}]`},
+		{expectedStatus: 200, expectedBody: `[{"username": "dreamer"}]`},
+	}
+
+	for _, tc := range testCases {
+		res, err := http.Get(fakeService.ResolveURL("/users"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the status code is what we expect
+		if status := res.StatusCode; status != tc.expectedStatus {
+			t.Errorf("request returned wrong status code: got %v want %v",
+				status, tc.expectedStatus)
+		}
+
+		// Check the response body is what we expect
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close() // nolint errcheck
+		if bodyString := string(body); bodyString != tc.expectedBody {
+			t.Errorf("request returned unexpected body: got %v want %v",
+				bodyString, tc.expectedBody)
+		}
+	}
+}
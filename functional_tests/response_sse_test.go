@@ -0,0 +1,78 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestResponseSSE tests a fake server handling GET requests
+// with a Server-Sent Events response
+func TestResponseSSE(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// register a handler for our fake service
+	fakeService.NewHandler().
+		Get("/notifications").
+		Reply(200).
+		SSE(
+			httpfake.Event{ID: "1", Event: "user", Data: `{"username": "dreamer"}`, Retry: time.Second},
+			httpfake.Event{ID: "2", Data: "first line\nsecond line", Delay: 10 * time.Millisecond},
+			httpfake.Event{ID: "3", Event: "done", Data: "bye"},
+		)
+
+	testCases := []struct {
+		name         string
+		lastEventID  string
+		expectedBody string
+	}{
+		{
+			name:        "all events",
+			lastEventID: "",
+			expectedBody: "id: 1\nevent: user\nretry: 1000\ndata: {\"username\": \"dreamer\"}\n\n" +
+				"id: 2\ndata: first line\ndata: second line\n\n" +
+				"id: 3\nevent: done\ndata: bye\n\n",
+		},
+		{
+			name:         "reconnection",
+			lastEventID:  "2",
+			expectedBody: "id: 3\nevent: done\ndata: bye\n\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", fakeService.ResolveURL("/notifications"), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close() // nolint errcheck
+
+			// Check the response header is what we expect
+			if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+				t.Errorf("request returned unexpected content type: got %v want %v",
+					contentType, "text/event-stream")
+			}
+
+			// Check the response body is what we expect
+			body, _ := ioutil.ReadAll(res.Body)
+			if bodyString := string(body); bodyString != tc.expectedBody {
+				t.Errorf("request returned unexpected body: got %q want %q",
+					bodyString, tc.expectedBody)
+			}
+		})
+	}
+}
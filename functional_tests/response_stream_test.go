@@ -0,0 +1,102 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestResponseStream tests a fake server handling GET requests
+// with streamed response bodies
+func TestResponseStream(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// register the handlers for our fake service
+	fakeService.NewHandler().
+		Get("/users").
+		Reply(200).
+		SetHeader("Content-Type", "application/x-ndjson").
+		ChunkDelay(20*time.Millisecond).
+		Stream(
+			[]byte(`{"username": "dreamer"}`+"\n"),
+			[]byte(`{"username": "sleeper"}`+"\n"),
+		)
+
+	fakeService.NewHandler().
+		Get("/clients").
+		Reply(200).
+		BodyReader(func() io.Reader {
+			return strings.NewReader(`{"name": "dreamer"}` + "\n")
+		})
+
+	fakeService.NewHandler().
+		Get("/events").
+		Reply(200).
+		ChunkDelay(time.Minute).
+		Stream([]byte("first\n"), []byte("second\n"))
+
+	res, err := http.Get(fakeService.ResolveURL("/users"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close() // nolint errcheck
+
+	// Check the response body is streamed as we expect
+	if len(res.TransferEncoding) == 0 || res.TransferEncoding[0] != "chunked" {
+		t.Errorf("request returned unexpected transfer encoding: got %v want %v", res.TransferEncoding, "chunked")
+	}
+	expected := `{"username": "dreamer"}` + "\n" + `{"username": "sleeper"}` + "\n"
+	if bodyString := string(body); bodyString != expected {
+		t.Errorf("request returned unexpected body: got %v want %v", bodyString, expected)
+	}
+
+	// Check every response body is read from a new reader as we expect
+	for i := 0; i < 2; i++ {
+		res, err = http.Get(fakeService.ResolveURL("/clients"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ = ioutil.ReadAll(res.Body)
+		res.Body.Close() // nolint errcheck
+
+		expected = `{"name": "dreamer"}` + "\n"
+		if bodyString := string(body); bodyString != expected {
+			t.Errorf("request %d returned unexpected body: got %v want %v", i+1, bodyString, expected)
+		}
+	}
+
+	// Check a partial read can be canceled mid-stream
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequest("GET", fakeService.ResolveURL("/events"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close() // nolint errcheck
+
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "first\n" {
+		t.Errorf("request returned unexpected first chunk: got %q want %q", line, "first\n")
+	}
+
+	cancel()
+	if _, err := io.Copy(ioutil.Discard, res.Body); err == nil {
+		t.Error("request returned no error reading the body after being canceled")
+	}
+}
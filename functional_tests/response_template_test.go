@@ -0,0 +1,132 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestResponseTemplate tests a fake server handling POST requests
+// with a response rendered from the request
+func TestResponseTemplate(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// register a handler for our fake service
+	fakeService.NewHandler().
+		Post("/stores/{store}/orders").
+		Reply(201).
+		Templated().
+		SetHeader("Location", "/stores/{{.Params.store}}/orders/{{.Body.id}}").
+		SetHeader("X-Request-Id", `{{.Header.Get "X-Request-Id"}}`).
+		BodyString(`{"id": "{{.Body.id}}", "store": "{{.Params.store}}", "source": "{{.Query.Get "source"}}", "call": {{.Call}}}`)
+
+	for call, id := range []string{"a1", "b2"} {
+		sendBody := bytes.NewBuffer([]byte(`{"id": "` + id + `", "items": [1, 2]}`))
+		req, err := http.NewRequest(http.MethodPost, fakeService.ResolveURL("/stores/main/orders?source=web"), sendBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Request-Id", "req-"+id)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the status code is what we expect
+		if status := res.StatusCode; status != 201 {
+			t.Errorf("request returned wrong status code: got %v want %v",
+				status, 201)
+		}
+
+		// Check the response headers are what we expect
+		if location := res.Header.Get("Location"); location != "/stores/main/orders/"+id {
+			t.Errorf("request returned unexpected value for header Location: got %v want %v",
+				location, "/stores/main/orders/"+id)
+		}
+		if requestID := res.Header.Get("X-Request-Id"); requestID != "req-"+id {
+			t.Errorf("request returned unexpected value for header X-Request-Id: got %v want %v",
+				requestID, "req-"+id)
+		}
+
+		// Check the response body is what we expect
+		expected := `{"id": "` + id + `", "store": "main", "source": "web", "call": ` + strconv.Itoa(call+1) + `}`
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close() // nolint errcheck
+		if bodyString := string(body); bodyString != expected {
+			t.Errorf("request returned unexpected body: got %v want %v",
+				bodyString, expected)
+		}
+	}
+}
+
+// TestResponseTemplateBody tests a fake server rendering the request body fields
+// the same way whether the request body is JSON or not
+func TestResponseTemplateBody(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// register a handler for our fake service
+	fakeService.NewHandler().
+		Post("/orders").
+		Reply(201).
+		Templated().
+		BodyString(`{"id": {{.Body.id}}{{with index .Body "note"}}, "note": "{{.}}"{{end}}}`)
+
+	testCases := []struct {
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{body: `{"id": 9007199254740993}`, expectedStatus: 201, expectedBody: `{"id": 9007199254740993}`},
+		{body: `{"id": 1, "note": "fragile"}`, expectedStatus: 201, expectedBody: `{"id": 1, "note": "fragile"}`},
+		{body: `{"note": "fragile"}`, expectedStatus: 500, expectedBody: ""},
+		{body: `id=1`, expectedStatus: 500, expectedBody: ""},
+		{body: ``, expectedStatus: 500, expectedBody: ""},
+	}
+
+	for _, tc := range testCases {
+		res, err := http.Post(fakeService.ResolveURL("/orders"), "application/json", bytes.NewBufferString(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the status code is what we expect
+		if status := res.StatusCode; status != tc.expectedStatus {
+			t.Errorf("request with body %q returned wrong status code: got %v want %v",
+				tc.body, status, tc.expectedStatus)
+		}
+
+		// Check the response body is what we expect
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close() // nolint errcheck
+		if bodyString := string(body); bodyString != tc.expectedBody {
+			t.Errorf("request with body %q returned unexpected body: got %v want %v",
+				tc.body, bodyString, tc.expectedBody)
+		}
+	}
+}
+
+// TestResponseTemplateInvalid tests setting up a templated response with an invalid template
+func TestResponseTemplateInvalid(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Close()
+
+	// Check the invalid template is a setup error
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("setting up the invalid template did not panic")
+		}
+	}()
+	fakeService.NewHandler().
+		Get("/orders").
+		Reply(200).
+		Templated().
+		BodyString(`{"id": "{{.Body.id"}`)
+}
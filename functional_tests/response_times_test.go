@@ -0,0 +1,58 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestResponseTimes tests a fake server handling GET requests
+// with a handler limited to a number of calls
+func TestResponseTimes(t *testing.T) {
+	fakeService := httpfake.New(httpfake.WithTesting(t), httpfake.WithStrictMatching())
+	defer fakeService.Server.Close()
+
+	// register the handlers for our fake service,
+	// the limited handler is used first even though the fallback is registered later
+	fakeService.NewHandler().
+		Get("/users").
+		Times(2).
+		Reply(500)
+
+	fakeService.NewHandler().
+		Get("/users").
+		Reply(200).
+		BodyString(`[{"username": "dreamer"}]`)
+
+	fakeService.NewHandler().
+		Get("/clients").
+		Once().
+		Reply(201)
+
+	testCases := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{path: "/users", expectedStatus: 500},
+		{path: "/users", expectedStatus: 500},
+		{path: "/users", expectedStatus: 200},
+		{path: "/clients", expectedStatus: 201},
+		{path: "/clients", expectedStatus: 404},
+	}
+
+	for _, tc := range testCases {
+		res, err := http.Get(fakeService.ResolveURL(tc.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close() // nolint errcheck
+
+		// Check the status code is what we expect
+		if status := res.StatusCode; status != tc.expectedStatus {
+			t.Errorf("request to %s returned wrong status code: got %v want %v",
+				tc.path, status, tc.expectedStatus)
+		}
+	}
+}
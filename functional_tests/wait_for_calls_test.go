@@ -0,0 +1,57 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestWaitForCalls tests waiting for a fake server
+// to receive requests sent from background goroutines
+func TestWaitForCalls(t *testing.T) {
+	fakeService := httpfake.New()
+	defer fakeService.Server.Close()
+
+	// register a handler for our fake service
+	handler := fakeService.NewHandler().
+		Post("/events")
+	handler.Reply(202)
+
+	for i := 0; i < 3; i++ {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			res, err := http.Post(fakeService.ResolveURL("/events"), "application/json", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res.Body.Close() // nolint errcheck
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := handler.WaitForCalls(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	err := fakeService.WaitFor(ctx, func(requests []*httpfake.RecordedRequest) bool {
+		return len(requests) == 3
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check waiting for more calls times out
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer shortCancel()
+
+	if err := handler.WaitForCalls(shortCtx, 4); err == nil {
+		t.Error("waiting for more calls than received returned no error")
+	}
+}
@@ -0,0 +1,153 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+// TestWebSocket tests a fake server handling a WebSocket connection
+// with a scripted conversation
+func TestWebSocket(t *testing.T) {
+	fakeService := httpfake.New(httpfake.WithTesting(t))
+	defer fakeService.Close()
+
+	// register a handler for our fake service
+	fakeService.NewHandler().
+		Get("/ws").
+		WebSocket(func(conn *httpfake.WebSocketConn, r *http.Request) error {
+			if _, err := conn.Expect(httpfake.MessageJSONSubset([]byte(`{"type": "subscribe"}`))); err != nil {
+				return err
+			}
+			if err := conn.SendJSON(map[string]string{"type": "subscribed"}); err != nil {
+				return err
+			}
+			if _, err := conn.Expect(httpfake.MessageText("ping")); err != nil {
+				return err
+			}
+			if err := conn.SendText("pong"); err != nil {
+				return err
+			}
+			return conn.Close(httpfake.WebSocketCloseGoingAway, "bye")
+		})
+
+	client, err := dialWebSocket(fakeService.ResolveURL("/ws"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.conn.Close() // nolint errcheck
+
+	testCases := []struct {
+		send     string
+		expected string
+	}{
+		{send: `{"type": "subscribe", "channel": "users"}`, expected: `{"type":"subscribed"}`},
+		{send: "ping", expected: "pong"},
+	}
+
+	for _, tc := range testCases {
+		if err := client.writeFrame(httpfake.WebSocketText, []byte(tc.send)); err != nil {
+			t.Fatal(err)
+		}
+
+		opcode, payload, err := client.readFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the message is what we expect
+		if opcode != httpfake.WebSocketText || string(payload) != tc.expected {
+			t.Errorf("websocket returned unexpected message: got %d %s want %d %s",
+				opcode, payload, httpfake.WebSocketText, tc.expected)
+		}
+	}
+
+	opcode, payload, err := client.readFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the connection is closed as we expect
+	if opcode != 8 || len(payload) < 2 || binary.BigEndian.Uint16(payload) != httpfake.WebSocketCloseGoingAway {
+		t.Errorf("websocket returned unexpected close frame: got %d %v", opcode, payload)
+	}
+	if err := client.writeFrame(8, payload[:2]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// webSocketClient is a minimal WebSocket client for testing the fake server
+type webSocketClient struct {
+	conn net.Conn
+	buf  *bufio.Reader
+}
+
+func dialWebSocket(url string) (*webSocketClient, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+
+	conn, err := net.DialTimeout("tcp", req.URL.Host, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second)) // nolint errcheck
+
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	buf := bufio.NewReader(conn)
+	res, err := http.ReadResponse(buf, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		return nil, errors.New("unexpected handshake status " + res.Status)
+	}
+	if accept := res.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		return nil, errors.New("unexpected handshake accept " + accept)
+	}
+
+	return &webSocketClient{conn: conn, buf: buf}, nil
+}
+
+func (c *webSocketClient) writeFrame(opcode byte, payload []byte) error {
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func (c *webSocketClient) readFrame() (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.buf, header); err != nil {
+		return 0, nil, err
+	}
+	if header[1] > 125 {
+		return 0, nil, errors.New("unexpected extended frame length")
+	}
+
+	payload := make([]byte, header[1])
+	if _, err := io.ReadFull(c.buf, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0] & 0x0f, payload, nil
+}
@@ -0,0 +1,213 @@
+// nolint dupl
+package functional_tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maxcnunes/httpfake"
+)
+
+const wireMockUsersMapping = `{
+  "request": {
+    "method": "GET",
+    "urlPath": "/users",
+    "headers": {
+      "Accept": {"equalTo": "application/json"}
+    },
+    "queryParameters": {
+      "page": {"matches": "[0-9]+"}
+    }
+  },
+  "response": {
+    "status": 200,
+    "headers": {"Content-Type": "application/json"},
+    "bodyFileName": "users.json"
+  }
+}`
+
+const wireMockOrdersMappings = `{
+  "mappings": [
+    {
+      "request": {
+        "method": "POST",
+        "url": "/orders",
+        "bodyPatterns": [
+          {"equalToJson": "{\"item\": \"book\"}", "ignoreExtraElements": true}
+        ]
+      },
+      "response": {
+        "status": 201,
+        "jsonBody": {"id": 1}
+      }
+    },
+    {
+      "request": {
+        "method": "ANY",
+        "urlPathPattern": "/orders/[0-9]+",
+        "headers": {
+          "Authorization": {"absent": true}
+        }
+      },
+      "response": {
+        "status": 401,
+        "base64Body": "dW5hdXRob3JpemVk",
+        "fixedDelayMilliseconds": 10
+      }
+    },
+    {
+      "request": {
+        "method": "GET",
+        "url": "/orders?status=open"
+      },
+      "response": {
+        "status": 200,
+        "body": "open orders"
+      }
+    },
+    {
+      "request": {
+        "method": "DELETE",
+        "urlPath": "/orders"
+      },
+      "response": {
+        "status": 405
+      }
+    }
+  ]
+}`
+
+// TestWireMock tests a fake server handling requests
+// with handlers loaded from WireMock stub mappings
+func TestWireMock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint errcheck
+
+	files := map[string]string{
+		"mappings/users.json":  wireMockUsersMapping,
+		"mappings/orders.json": wireMockOrdersMappings,
+		"__files/users.json":   `[{"username": "dreamer"}]`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fakeService := httpfake.New(httpfake.WithTesting(t))
+	defer fakeService.Close()
+
+	// register the handlers defined in the WireMock mappings
+	if err := fakeService.LoadWireMock(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		method         string
+		path           string
+		header         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			method:         "GET",
+			path:           "/users?page=2",
+			header:         "Accept",
+			expectedStatus: 200,
+			expectedBody:   `[{"username": "dreamer"}]`,
+		},
+		{
+			method:         "GET",
+			path:           "/users?page=last",
+			header:         "Accept",
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+		{
+			method:         "POST",
+			path:           "/orders",
+			body:           `{"item": "book", "quantity": 1}`,
+			expectedStatus: 201,
+			expectedBody:   `{"id": 1}`,
+		},
+		{
+			method:         "POST",
+			path:           "/orders",
+			body:           `{"item": "pen"}`,
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+		{
+			method:         "GET",
+			path:           "/orders?status=open",
+			expectedStatus: 200,
+			expectedBody:   "open orders",
+		},
+		{
+			method:         "GET",
+			path:           "/orders?status=open&page=2",
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+		{
+			method:         "GET",
+			path:           "/orders",
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+		{
+			method:         "DELETE",
+			path:           "/orders/1",
+			expectedStatus: 401,
+			expectedBody:   "unauthorized",
+		},
+		{
+			method:         "PUT",
+			path:           "/orders/1",
+			header:         "Authorization",
+			expectedStatus: 404,
+			expectedBody:   "",
+		},
+	}
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(tc.method, fakeService.ResolveURL(tc.path), strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.header != "" {
+			req.Header.Set(tc.header, "application/json")
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the status code is what we expect
+		if status := res.StatusCode; status != tc.expectedStatus {
+			t.Errorf("request returned wrong status code: got %v want %v",
+				status, tc.expectedStatus)
+		}
+
+		// Check the response body is what we expect
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close() // nolint errcheck
+		if bodyString := string(body); bodyString != tc.expectedBody {
+			t.Errorf("request returned unexpected body: got %v want %v",
+				bodyString, tc.expectedBody)
+		}
+	}
+}
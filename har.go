@@ -0,0 +1,264 @@
+package httpfake
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// harLog is the root of a HAR 1.2 file, see http://www.softwareishard.com/blog/har-12-spec/
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harSkippedHeaders are the response headers not replayed from a HAR file,
+// since the recorded content is already decoded and is written again by the fake server
+var harSkippedHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Content-Encoding":  true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Keep-Alive":        true,
+}
+
+// WriteHAR writes all the requests received by the fake server along with their responses as a HAR 1.2 file,
+// which can be inspected with the browser developer tools and other HAR viewers.
+// Each entry is commented with the request handler matched for the request.
+// The data written to hijacked connections, such as WebSocket messages, is not included.
+// Example:
+//     if t.Failed() {
+//         f, _ := os.Create("failed.har")
+//         defer f.Close()
+//         fakeService.WriteHAR(f)
+//     }
+func (f *HTTPFake) WriteHAR(w io.Writer) error {
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator = harCreator{Name: "httpfake", Version: "1.0"}
+	har.Log.Entries = []harEntry{}
+
+	f.mu.Lock()
+	for _, recorded := range f.journal {
+		har.Log.Entries = append(har.Log.Entries, f.harEntry(recorded))
+	}
+	f.mu.Unlock()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(har)
+}
+
+// harEntry converts the recorded request and its response to a HAR entry
+func (f *HTTPFake) harEntry(recorded *RecordedRequest) harEntry {
+	entry := harEntry{
+		StartedDateTime: recorded.Time.Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      recorded.Method,
+			URL:         f.Server.URL + recorded.URL.RequestURI(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(recorded.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(recorded.Body),
+		},
+		Response: harResponse{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+		},
+	}
+
+	query := recorded.URL.Query()
+	for _, key := range sortedKeys(query) {
+		for _, value := range query[key] {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: key, Value: value})
+		}
+	}
+
+	if len(recorded.Body) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: recorded.Header.Get("Content-Type"),
+			Text:     string(recorded.Body),
+		}
+	}
+
+	if recorded.Handler != nil {
+		entry.Comment = "request handler " + recorded.Handler.describe()
+	} else {
+		entry.Comment = "not found request handler"
+	}
+
+	res := recorded.response
+	if res == nil {
+		// the response was not completed yet
+		return entry
+	}
+
+	entry.Time = float64(res.time.Sub(recorded.Time)) / float64(time.Millisecond)
+	entry.Timings.Wait = entry.Time
+	entry.Response.Status = res.status
+	entry.Response.StatusText = http.StatusText(res.status)
+	entry.Response.Headers = harHeaders(res.header)
+	entry.Response.BodySize = len(res.body)
+	entry.Response.Content = harContent{
+		Size:     len(res.body),
+		MimeType: res.header.Get("Content-Type"),
+	}
+	if utf8.Valid(res.body) {
+		entry.Response.Content.Text = string(res.body)
+	} else {
+		entry.Response.Content.Text = base64.StdEncoding.EncodeToString(res.body)
+		entry.Response.Content.Encoding = "base64"
+	}
+
+	return entry
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for _, key := range sortedKeys(header) {
+		for _, value := range header[key] {
+			headers = append(headers, harNameValue{Name: key, Value: value})
+		}
+	}
+	return headers
+}
+
+// LoadHAR registers request handlers replaying the entries of a HAR file, such as the ones exported
+// by the browser developer tools or by WriteHAR. Like LoadCassette, the requests are matched by method,
+// URL (path and query string) and body, and the repeated requests are responded in the recorded order.
+// The entries without a response, e.g. the requests blocked by the browser, are skipped.
+// The handlers are not expected to be called by Close.
+// Example:
+//     fakeService.LoadHAR("testdata/checkout.har")
+func (f *HTTPFake) LoadHAR(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var har harLog
+	if err := json.Unmarshal(data, &har); err != nil {
+		return fmt.Errorf("loading HAR %s: %w", path, err)
+	}
+
+	interactions := make([]interaction, 0, len(har.Log.Entries))
+	for i, entry := range har.Log.Entries {
+		if entry.Response.Status == 0 {
+			continue
+		}
+
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return fmt.Errorf("loading HAR %s: entry %d: %w", path, i, err)
+		}
+
+		var body []byte
+		if entry.Request.PostData != nil && entry.Request.PostData.Text != "" {
+			body = []byte(entry.Request.PostData.Text)
+		}
+
+		responseBody := []byte(entry.Response.Content.Text)
+		if entry.Response.Content.Encoding == "base64" {
+			if responseBody, err = base64.StdEncoding.DecodeString(entry.Response.Content.Text); err != nil {
+				return fmt.Errorf("loading HAR %s: entry %d: invalid response content: %w", path, i, err)
+			}
+		}
+
+		header := http.Header{}
+		for _, h := range entry.Response.Headers {
+			// the HTTP/2 pseudo headers, e.g. ":status", are not replayed either
+			if strings.HasPrefix(h.Name, ":") || harSkippedHeaders[http.CanonicalHeaderKey(h.Name)] {
+				continue
+			}
+			header.Add(h.Name, h.Value)
+		}
+
+		interactions = append(interactions, interaction{
+			method:       entry.Request.Method,
+			url:          u.RequestURI(),
+			body:         body,
+			status:       entry.Response.Status,
+			header:       header,
+			responseBody: responseBody,
+		})
+	}
+
+	f.registerInteractions(interactions)
+	return nil
+}
@@ -6,11 +6,15 @@
 package httpfake
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
-	netURL "net/url"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -19,14 +23,39 @@ type HTTPFake struct {
 	Server          *httptest.Server
 	RequestHandlers []*Request
 	t               testing.TB
+	strictMatching  bool
+	recorder        *recorder
+	contract        *contract
+	journal         []*RecordedRequest
+	notify          chan struct{}
+	cancel          context.CancelFunc
+	handlers        sync.WaitGroup
+	mu              sync.Mutex
 }
 
+type contextKey int
+
+const (
+	// callKey is the context key for the number of the call to the request handler
+	// matched for the incoming request, starting at 1
+	callKey contextKey = iota
+	// testingKey is the context key for the testing object set with WithTesting
+	testingKey
+	// bodyKey is the context key for the incoming request body buffered by the fake server
+	bodyKey
+)
+
 // ServerOption provides a functional signature for providing configuration options to the fake server
 type ServerOption func(opts *ServerOptions)
 
 // ServerOptions a configuration object for the fake test server
 type ServerOptions struct {
-	t testing.TB
+	t              testing.TB
+	strictMatching bool
+	upstream       string
+	cassette       string
+	openAPIPath    string
+	maxBodySize    int64
 }
 
 // WithTesting returns a configuration function that allows you to configure the testing object on the fake server.
@@ -38,6 +67,49 @@ func WithTesting(t testing.TB) ServerOption {
 	}
 }
 
+// WithStrictMatching returns a configuration function that reports ambiguous requests, which are the requests
+// matched by more than one request handler with the same precedence. Each ambiguous request fails the test
+// through the testing object set with WithTesting, listing all the candidate handlers.
+// Without this option the handler registered last is used silently.
+func WithStrictMatching() ServerOption {
+	return func(opts *ServerOptions) {
+		opts.strictMatching = true
+	}
+}
+
+// WithRecording returns a configuration function that forwards the requests not matched by any request handler
+// to the upstream server, given as an absolute URL, and records them along with the upstream responses.
+// The recorded interactions are saved to the cassette file on Close and can be replayed with LoadCassette,
+// so the tests do not need to reach the upstream server anymore.
+func WithRecording(upstream, cassette string) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.upstream = upstream
+		opts.cassette = cassette
+	}
+}
+
+// WithOpenAPIValidation returns a configuration function that validates every request received by the fake server
+// and every response sent back by the request handlers against the OpenAPI 3 document at the given path, written
+// in JSON. The requests are validated for their path, parameters, required headers and JSON body, and the
+// responses for their status, required headers and JSON body. The responses configured for each request handler
+// are validated as well on Close, even if the handler was never called. Each violation of the contract fails the
+// test through the testing object set with WithTesting, which is required by this option.
+func WithOpenAPIValidation(path string) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.openAPIPath = path
+	}
+}
+
+// WithMaxBodySize returns a configuration function that sets the size cap, in bytes, of the request bodies buffered
+// by the fake server. The requests with a larger body are responded with 413 Request Entity Too Large without
+// reaching any request handler, and are recorded in the journal without their body.
+// The cap is 10 MB when this option is not set. A size of 0 or less removes the cap.
+func WithMaxBodySize(size int64) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.maxBodySize = size
+	}
+}
+
 // New starts a httptest.Server as the fake server
 // and sets up the initial configuration to this server's request handlers
 func New(opts ...ServerOption) *HTTPFake {
@@ -45,37 +117,107 @@ func New(opts ...ServerOption) *HTTPFake {
 		RequestHandlers: []*Request{},
 	}
 
-	var serverOpts ServerOptions
+	serverOpts := ServerOptions{maxBodySize: defaultMaxBodySize}
 	for _, opt := range opts {
 		opt(&serverOpts)
 	}
 
 	fake.t = serverOpts.t
-	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rh, err := fake.findHandler(r)
+	fake.strictMatching = serverOpts.strictMatching
+	if serverOpts.upstream != "" {
+		fake.recorder = newRecorder(serverOpts.upstream, serverOpts.cassette)
+	}
+	if serverOpts.openAPIPath != "" {
+		if fake.t == nil {
+			panic("setup error: \"WithTesting\" is required when \"WithOpenAPIValidation\" is set")
+		}
+		fake.contract = newContract(serverOpts.openAPIPath)
+	}
+	fake.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// tracks the handlers of hijacked connections as well,
+		// which are not awaited by the httptest.Server
+		fake.handlers.Add(1)
+		defer fake.handlers.Done()
+
+		// the body is read before anything else so it is recorded
+		// even if the responder never reads it
+		r, body, err := bufferBody(r, serverOpts.maxBodySize)
+		if err != nil && err != errBodyTooLarge {
+			printError(fmt.Sprintf("error reading request body: %v", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		recorded := newRecordedRequest(r, body)
+
+		// the response is captured for the journal as well
+		rec := &responseRecorder{ResponseWriter: w}
+		defer fake.recordResponse(recorded, rec)
+		w = rec
+
+		// the requests with a body over the size cap are recorded without their body
+		// and rejected before reaching any request handler
+		if err == errBodyTooLarge {
+			fake.mu.Lock()
+			fake.addToJournal(recorded)
+			fake.mu.Unlock()
+
+			fake.reportBodyTooLarge(r, serverOpts.maxBodySize)
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		// finding the handler and counting the call must happen at once
+		// so concurrent requests respect the handlers call limit
+		fake.mu.Lock()
+		rh, candidates, err := fake.findHandler(r)
+		var call int
+		if rh != nil {
+			recorded.Handler = rh
+			call = rh.addCall(recorded)
+		}
+		fake.addToJournal(recorded)
+		fake.mu.Unlock()
+
 		if err != nil {
 			printError(fmt.Sprintf("error finding handler: %v", err))
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
+		if fake.contract != nil {
+			fake.contract.validateRequest(fake.t, r, body)
+		}
+
+		if len(candidates) > 0 && fake.strictMatching {
+			fake.reportAmbiguity(r, candidates)
+		}
+
+		ctx := context.WithValue(r.Context(), callKey, call)
+		if fake.t != nil {
+			ctx = context.WithValue(ctx, testingKey, fake.t)
+		}
+		r = r.WithContext(ctx)
+
+		if rh == nil && fake.recorder != nil {
+			// the upstream gets a fresh reader even if the matchers read the body
+			resetBody(r)
+			fake.recorder.forward(w, r, body)
+			return
+		}
+
 		if rh == nil {
 			errMsg := fmt.Sprintf(
 				"not found request handler for [%s: %s]; registered handlers are:\n",
 				r.Method, r.URL,
 			)
 			for _, frh := range fake.RequestHandlers {
-				errMsg += fmt.Sprintf("* [%s: %s]\n", frh.Method, frh.URL.Path)
+				errMsg += fmt.Sprintf("* %s\n", frh.describe())
 			}
 			printError(errMsg)
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
 
-		rh.Lock()
-		rh.called++
-		rh.Unlock()
-
 		if rh.assertions != nil {
 			if fake.t == nil {
 				errMsg := fmt.Sprintf("setup error: \"WithTesting\" is required when assertions are set")
@@ -85,6 +227,13 @@ func New(opts ...ServerOption) *HTTPFake {
 			rh.runAssertions(fake.t, r)
 		}
 
+		if fake.contract != nil {
+			defer fake.contract.validateResponse(fake.t, r, rec)
+		}
+
+		// the responders get a fresh reader even if the assertions read the body
+		resetBody(r)
+
 		if rh.CustomHandle != nil {
 			rh.CustomHandle(w, r, rh)
 			return
@@ -93,6 +242,15 @@ func New(opts ...ServerOption) *HTTPFake {
 		Respond(w, r, rh)
 	}))
 
+	// the requests context is canceled on Close
+	// so the delayed responses do not hold the server
+	ctx, cancel := context.WithCancel(context.Background())
+	fake.cancel = cancel
+	fake.Server.Config.BaseContext = func(net.Listener) context.Context {
+		return ctx
+	}
+	fake.Server.Start()
+
 	return fake
 }
 
@@ -116,50 +274,162 @@ func (f *HTTPFake) Reset() *HTTPFake {
 }
 
 // Close shuts down the HTTP Test server, this will block until all outstanding requests on the server have completed.
+// If the WithRecording option was specified the recorded interactions are saved to the cassette file.
+// If the WithOpenAPIValidation option was specified the responses configured for each http handler are validated
+// against the OpenAPI contract.
 // If the WithTesting option was specified when setting up the server Close will assert that each http handler
-// specified for this server was called
+// specified for this server was called, or was called as many times as expected when
+// ExpectCalls, ExpectAtLeast, ExpectAtMost or ExpectNever was set for the handler
 func (f *HTTPFake) Close() {
-	defer f.Server.Close()
+	f.cancel()
+	f.Server.Close()
+	f.handlers.Wait()
+
+	if f.contract != nil {
+		for _, reqHandler := range f.RequestHandlers {
+			f.contract.validateHandler(f.t, reqHandler)
+		}
+	}
 
 	if f.t != nil {
 		for _, reqHandler := range f.RequestHandlers {
-			if reqHandler.called == 0 {
-				f.t.Errorf("httpfake: request handler was specified but not called %s", reqHandler.URL.Path)
+			if err := reqHandler.verifyCalls(); err != nil {
+				f.t.Errorf("httpfake: request handler %s %s", reqHandler.describe(), err)
 			}
 		}
 	}
+
+	if f.recorder != nil {
+		if err := f.recorder.save(); err != nil {
+			errMsg := fmt.Sprintf("error saving cassette %s: %v", f.recorder.path, err)
+			if f.t == nil {
+				printError(errMsg)
+				return
+			}
+			f.t.Errorf("httpfake: %s", errMsg)
+		}
+	}
 }
 
-func (f *HTTPFake) findHandler(r *http.Request) (*Request, error) {
-	founds := []*Request{}
-	url := r.URL.String()
-	path := getURLPath(url)
+// findHandler returns the most specific request handler for the incoming request.
+// The handlers are ranked by:
+//  1. how their URL matches the request: the exact URL (path and query string),
+//     then the exact path, then a path with parameters and at last a wildcard or regular expression
+//  2. the number of matchers set on the handler, more matchers beats fewer
+//  3. the limit set with Times, a limited handler beats an unlimited one until its calls run out
+//  4. the registration order, a handler registered later overrides the ones registered earlier
+//
+// It also returns all the handlers sharing the best rank when the request is ambiguous.
+func (f *HTTPFake) findHandler(r *http.Request) (*Request, []*Request, error) {
+	var found *Request
+	var foundRank handlerRank
+	var candidates []*Request
 	for _, rh := range f.RequestHandlers {
-		if rh.Method != r.Method {
+		if (rh.Method != r.Method && rh.Method != anyMethod) || rh.exhausted() {
 			continue
 		}
 
-		rhURL, err := netURL.QueryUnescape(rh.URL.String())
+		kind, err := rh.matchURL(r.URL)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		if rhURL == url {
-			return rh, nil
+		if kind == noMatch || !rh.runMatchers(r) {
+			continue
 		}
 
-		// fallback if the income request has query strings
-		// and there is handlers only for the path
-		if getURLPath(rhURL) == path {
-			founds = append(founds, rh)
+		rank := handlerRank{kind: kind, matchers: len(rh.matchers), limited: rh.limit > 0}
+		switch {
+		case found == nil || foundRank.less(rank):
+			found, foundRank = rh, rank
+			candidates = []*Request{rh}
+		case rank == foundRank:
+			found = rh
+			candidates = append(candidates, rh)
 		}
 	}
-	// only use the fallback if could find only one match
-	if len(founds) == 1 {
-		return founds[0], nil
+
+	if len(candidates) < 2 {
+		candidates = nil
+	}
+
+	return found, candidates, nil
+}
+
+// handlerRank stores how specific a request handler is for an incoming request
+type handlerRank struct {
+	kind     matchKind
+	matchers int
+	// limited is set for the handlers limited with Times, which are used before
+	// the unlimited handlers as specific as them until their calls run out
+	limited bool
+}
+
+func (h handlerRank) less(other handlerRank) bool {
+	if h.kind != other.kind {
+		return h.kind < other.kind
+	}
+	if h.matchers != other.matchers {
+		return h.matchers < other.matchers
+	}
+	return !h.limited && other.limited
+}
+
+func (f *HTTPFake) reportAmbiguity(r *http.Request, candidates []*Request) {
+	errMsg := fmt.Sprintf(
+		"ambiguous request handlers for [%s: %s]; candidate handlers are:\n",
+		r.Method, r.URL,
+	)
+	for _, rh := range candidates {
+		errMsg += fmt.Sprintf("* %s\n", rh.describe())
 	}
 
-	return nil, nil
+	if f.t == nil {
+		printError(errMsg)
+		return
+	}
+
+	f.t.Errorf("httpfake: %s", errMsg)
+}
+
+// reportBodyTooLarge fails the test for a request with a body larger than the size cap set with WithMaxBodySize
+func (f *HTTPFake) reportBodyTooLarge(r *http.Request, maxSize int64) {
+	errMsg := fmt.Sprintf("request body for [%s: %s] is larger than the maximum size of %d bytes", r.Method, r.URL, maxSize)
+	if f.t == nil {
+		printError(errMsg)
+		return
+	}
+
+	f.t.Errorf("httpfake: %s", errMsg)
+}
+
+// callNumber returns the number of the call to the request handler for the incoming request
+func callNumber(r *http.Request) int {
+	call, _ := r.Context().Value(callKey).(int)
+	return call
+}
+
+// reportError fails the test with the given message through the testing object set with WithTesting
+// or prints the message when there is no testing object
+func reportError(r *http.Request, msg string) {
+	t, ok := r.Context().Value(testingKey).(testing.TB)
+	if !ok {
+		printError(msg)
+		return
+	}
+
+	t.Errorf("httpfake: %s", msg)
+}
+
+// sortedKeys returns the keys of a map with string keys in order
+func sortedKeys(m interface{}) []string {
+	mapKeys := reflect.ValueOf(m).MapKeys()
+	keys := make([]string, len(mapKeys))
+	for i, key := range mapKeys {
+		keys[i] = key.String()
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func getURLPath(url string) string {
@@ -1,6 +1,9 @@
 package httpfake
 
 import (
+	"bytes"
+	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -32,3 +35,130 @@ func TestResolveURL(t *testing.T) {
 	}
 
 }
+
+func TestStrictMatching(t *testing.T) {
+	mt := &mockTester{buf: &bytes.Buffer{}}
+	fakeService := New(WithTesting(mt), WithStrictMatching())
+	defer fakeService.Server.Close()
+
+	fakeService.NewHandler().
+		Get("/users").
+		MatchHeader("Accept", "application/json").
+		Reply(200)
+
+	fakeService.NewHandler().
+		Get("/users").
+		MatchQuery("page", "1").
+		Reply(200)
+
+	fakeService.NewHandler().
+		Get("/clients").
+		Reply(200)
+
+	req, err := http.NewRequest(http.MethodGet, fakeService.ResolveURL("/users?page=1"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close() // nolint errcheck
+
+	expected := "httpfake: ambiguous request handlers for [GET: /users?page=1]; candidate handlers are:\n" +
+		"* [GET: /users] with header Accept=application/json\n" +
+		"* [GET: /users] with query page=1\n"
+	if msg := mt.buf.String(); msg != expected {
+		t.Errorf("returned unexpected error message: got %q want %q", msg, expected)
+	}
+}
+
+func TestCloseVerifiesCalls(t *testing.T) {
+	mt := &mockTester{buf: &bytes.Buffer{}}
+	fakeService := New(WithTesting(mt))
+
+	fakeService.NewHandler().
+		Post("/webhooks").
+		ExpectCalls(1).
+		Reply(204)
+
+	fakeService.NewHandler().
+		Delete("/users").
+		ExpectNever().
+		Reply(204)
+
+	fakeService.NewHandler().
+		Get("/users").
+		Reply(200)
+
+	for i := 0; i < 2; i++ {
+		res, err := http.Post(fakeService.ResolveURL("/webhooks"), "application/json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close() // nolint errcheck
+	}
+
+	fakeService.Close()
+
+	expected := "httpfake: request handler [POST: /webhooks] was expected to be called 1 time but was called 2 times" +
+		"httpfake: request handler [GET: /users] was specified but not called"
+	if msg := mt.buf.String(); msg != expected {
+		t.Errorf("returned unexpected error message: got %q want %q", msg, expected)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	mt := &mockTester{buf: &bytes.Buffer{}}
+	fakeService := New(WithTesting(mt), WithMaxBodySize(16))
+	defer fakeService.Server.Close()
+
+	fakeService.NewHandler().
+		Post("/users").
+		Reply(201)
+
+	testCases := []struct {
+		body           string
+		expectedStatus int
+		expectedErr    string
+	}{
+		{
+			body:           `{"id": 1}`,
+			expectedStatus: http.StatusCreated,
+			expectedErr:    "",
+		},
+		{
+			body:           `{"id": 1, "username": "dreamer"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedErr:    "httpfake: request body for [POST: /users] is larger than the maximum size of 16 bytes",
+		},
+	}
+
+	for _, tc := range testCases {
+		mt.buf.Reset()
+
+		res, err := http.Post(fakeService.ResolveURL("/users"), "application/json", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close() // nolint errcheck
+
+		if res.StatusCode != tc.expectedStatus {
+			t.Errorf("request returned wrong status code: got %v want %v", res.StatusCode, tc.expectedStatus)
+		}
+		if msg := mt.buf.String(); msg != tc.expectedErr {
+			t.Errorf("returned unexpected error message: got %q want %q", msg, tc.expectedErr)
+		}
+	}
+
+	// Check the rejected request is recorded without its body
+	requests := fakeService.Requests()
+	if len(requests) != len(testCases) {
+		t.Fatalf("recorded unexpected number of requests: got %v want %v", len(requests), len(testCases))
+	}
+	if rejected := requests[1]; rejected.Handler != nil || len(rejected.Body) != 0 {
+		t.Errorf("recorded unexpected rejected request: got handler %v and body %q", rejected.Handler, rejected.Body)
+	}
+}
@@ -0,0 +1,152 @@
+package httpfake
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RecordedRequest stores a request received by the fake server
+type RecordedRequest struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+	Time   time.Time
+	// Handler is the request handler matched for the request, or nil if no handler was found
+	Handler *Request
+	// response is set once the response is complete, guarded by the fake server mutex
+	response *recordedResponse
+}
+
+// recordedResponse stores the response sent back to a recorded request
+type recordedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+	time   time.Time
+}
+
+// newRecordedRequest records the incoming request and its already read body
+func newRecordedRequest(r *http.Request, body []byte) *RecordedRequest {
+	u := *r.URL
+	return &RecordedRequest{
+		Method: r.Method,
+		URL:    &u,
+		Header: r.Header.Clone(),
+		Body:   body,
+		Time:   time.Now(),
+	}
+}
+
+// Requests returns all the requests received by the fake server in the order they arrived,
+// including the ones that did not match any request handler
+func (f *HTTPFake) Requests() []*RecordedRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	requests := make([]*RecordedRequest, len(f.journal))
+	copy(requests, f.journal)
+	return requests
+}
+
+// Calls returns all the requests responded by this request handler in the order they arrived
+func (r *Request) Calls() []*RecordedRequest {
+	r.Lock()
+	defer r.Unlock()
+
+	calls := make([]*RecordedRequest, len(r.calls))
+	copy(calls, r.calls)
+	return calls
+}
+
+// addToJournal records an incoming request and wakes up the callers waiting for requests.
+// The caller must hold f.mu.
+func (f *HTTPFake) addToJournal(recorded *RecordedRequest) {
+	f.journal = append(f.journal, recorded)
+	if f.notify != nil {
+		close(f.notify)
+		f.notify = nil
+	}
+}
+
+// recordResponse records the response captured by the responseRecorder for the recorded request
+func (f *HTTPFake) recordResponse(recorded *RecordedRequest, w *responseRecorder) {
+	status, header := w.result()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	recorded.response = &recordedResponse{
+		status: status,
+		header: header,
+		body:   w.body.Bytes(),
+		time:   time.Now(),
+	}
+}
+
+// responseRecorder captures the response written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	hijacked bool
+}
+
+// result returns the status and the headers of the response
+func (w *responseRecorder) result() (int, http.Header) {
+	if w.status == 0 && !w.hijacked {
+		// net/http replies 200 when the handler writes nothing
+		return http.StatusOK, w.Header().Clone()
+	}
+	return w.status, w.header
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.status == 0 && !w.hijacked {
+		w.status = status
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher when the underlying response writer does
+func (w *responseRecorder) Flush() {
+	flusher, ok := w.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+		w.header = w.Header().Clone()
+	}
+	flusher.Flush()
+}
+
+// Hijack implements http.Hijacker when the underlying response writer does,
+// the data written to a hijacked connection is not recorded
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking the connection")
+	}
+	w.hijacked = true
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying response writer for http.ResponseController
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,197 @@
+package httpfake
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonEqual checks if both JSON documents hold the same values
+// regardless of whitespace and the order of the object keys
+func jsonEqual(expected, actual []byte) bool {
+	expectedValue, err := decodeJSON(expected)
+	if err != nil {
+		return false
+	}
+	actualValue, err := decodeJSON(actual)
+	if err != nil {
+		return false
+	}
+	return len(jsonDiff(expectedValue, actualValue, "$", false)) == 0
+}
+
+// decodeJSON decodes a JSON document keeping the numbers as json.Number,
+// so the large integers such as generated IDs are compared without losing precision
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid data after top-level value")
+	}
+	return value, nil
+}
+
+// jsonNumber parses a number decoded from a JSON document as an exact rational number
+func jsonNumber(value interface{}) (*big.Rat, bool) {
+	var text string
+	switch n := value.(type) {
+	case json.Number:
+		text = n.String()
+	case float64:
+		text = strconv.FormatFloat(n, 'g', -1, 64)
+	default:
+		return nil, false
+	}
+	return new(big.Rat).SetString(text)
+}
+
+// jsonContains checks if the actual JSON value contains the expected one.
+// Objects match when every expected key is present with a matching value,
+// arrays match when every expected item is contained by the actual item at the same position
+// and any other value must be equal.
+func jsonContains(expected, actual interface{}) bool {
+	return len(jsonDiff(expected, actual, "$", true)) == 0
+}
+
+// jsonDiff returns the differences between the expected and actual values decoded from JSON documents,
+// one for each value with the path where it was found, e.g. `$.users[0].id: expected 1, got 2`.
+// When subset is set, the keys and items of the actual value which are not expected are ignored.
+func jsonDiff(expected, actual interface{}, path string, subset bool) []string {
+	switch expectedValue := expected.(type) {
+	case map[string]interface{}:
+		actualValue, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		var diff []string
+		for _, key := range sortedKeys(expectedValue) {
+			v, ok := actualValue[key]
+			if !ok {
+				diff = append(diff, fmt.Sprintf("%s.%s: expected %s, got nothing", path, key, formatJSON(expectedValue[key])))
+				continue
+			}
+			diff = append(diff, jsonDiff(expectedValue[key], v, path+"."+key, subset)...)
+		}
+		if !subset {
+			for _, key := range sortedKeys(actualValue) {
+				if _, ok := expectedValue[key]; !ok {
+					diff = append(diff, fmt.Sprintf("%s.%s: unexpected %s", path, key, formatJSON(actualValue[key])))
+				}
+			}
+		}
+		return diff
+	case []interface{}:
+		actualValue, ok := actual.([]interface{})
+		if !ok {
+			break
+		}
+
+		var diff []string
+		switch {
+		case subset && len(actualValue) < len(expectedValue):
+			diff = append(diff, fmt.Sprintf("%s: expected at least %d items, got %d", path, len(expectedValue), len(actualValue)))
+		case !subset && len(actualValue) != len(expectedValue):
+			diff = append(diff, fmt.Sprintf("%s: expected %d items, got %d", path, len(expectedValue), len(actualValue)))
+		}
+		for i := 0; i < len(expectedValue) && i < len(actualValue); i++ {
+			diff = append(diff, jsonDiff(expectedValue[i], actualValue[i], fmt.Sprintf("%s[%d]", path, i), subset)...)
+		}
+		return diff
+	}
+
+	if expectedNumber, ok := jsonNumber(expected); ok {
+		if actualNumber, ok := jsonNumber(actual); ok && expectedNumber.Cmp(actualNumber) == 0 {
+			return nil
+		}
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, formatJSON(expected), formatJSON(actual))}
+	}
+	return nil
+}
+
+// splitJSONPath splits a path to a JSON value, e.g. "$.users[0].id",
+// into its object keys (strings) and array indexes (ints)
+func splitJSONPath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path %q must start with $", path)
+	}
+
+	var segments []interface{}
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("path %q has an empty key", path)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %q has an unclosed index", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("path %q has an invalid index %q", path, rest[1:end])
+			}
+			segments = append(segments, index)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("path %q has an unexpected character %q", path, rest[0])
+		}
+	}
+	return segments, nil
+}
+
+// jsonPathValue returns the value found at the path of a value decoded from a JSON document, e.g. "$.users[0].id"
+func jsonPathValue(value interface{}, path string) (interface{}, error) {
+	segments, err := splitJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := "$"
+	for _, segment := range segments {
+		switch s := segment.(type) {
+		case string:
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is %s, not an object", current, jsonType(value))
+			}
+			current += "." + s
+			if value, ok = obj[s]; !ok {
+				return nil, fmt.Errorf("%s is missing", current)
+			}
+		case int:
+			list, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is %s, not an array", current, jsonType(value))
+			}
+			current += fmt.Sprintf("[%d]", s)
+			if s >= len(list) {
+				return nil, fmt.Errorf("%s is missing", current)
+			}
+			value = list[s]
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,157 @@
+package httpfake
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		subset   bool
+		diff     []string
+	}{
+		{
+			name:     "equal regardless of key order",
+			expected: `{"id": 1, "roles": ["admin"]}`,
+			actual:   `{"roles": ["admin"], "id": 1}`,
+			diff:     nil,
+		},
+		{
+			name:     "different values",
+			expected: `{"id": 1, "user": {"username": "dreamer", "email": "dreamer@example.com"}, "roles": ["admin", "user"]}`,
+			actual:   `{"id": "1", "user": {"username": "sleeper", "age": 30}, "roles": ["admin"]}`,
+			diff: []string{
+				`$.id: expected 1, got "1"`,
+				`$.roles: expected 2 items, got 1`,
+				`$.user.email: expected "dreamer@example.com", got nothing`,
+				`$.user.username: expected "dreamer", got "sleeper"`,
+				`$.user.age: unexpected 30`,
+			},
+		},
+		{
+			name:     "subset",
+			expected: `{"user": {"username": "dreamer"}, "roles": ["admin"]}`,
+			actual:   `{"id": 1, "user": {"username": "dreamer", "age": 30}, "roles": ["admin", "user"]}`,
+			subset:   true,
+			diff:     nil,
+		},
+		{
+			name:     "subset with different values",
+			expected: `{"users": [{"id": 1}, {"id": 2}]}`,
+			actual:   `{"users": [{"id": 2, "username": "sleeper"}]}`,
+			subset:   true,
+			diff: []string{
+				`$.users: expected at least 2 items, got 1`,
+				`$.users[0].id: expected 1, got 2`,
+			},
+		},
+		{
+			name:     "different types",
+			expected: `{"user": {"id": 1}}`,
+			actual:   `{"user": [1]}`,
+			diff:     []string{`$.user: expected {"id":1}, got [1]`},
+		},
+		{
+			name:     "large integers",
+			expected: `{"id": 9007199254740993}`,
+			actual:   `{"id": 9007199254740992}`,
+			diff:     []string{`$.id: expected 9007199254740993, got 9007199254740992`},
+		},
+		{
+			name:     "same numbers written differently",
+			expected: `{"id": 100, "price": 1.5}`,
+			actual:   `{"id": 1e2, "price": 1.50}`,
+			diff:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := decodeJSON([]byte(tt.expected))
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := decodeJSON([]byte(tt.actual))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := jsonDiff(expected, actual, "$", tt.subset); !reflect.DeepEqual(diff, tt.diff) {
+				t.Errorf("jsonDiff() = %q, expected %q", diff, tt.diff)
+			}
+		})
+	}
+}
+
+func TestJSONPathValue(t *testing.T) {
+	doc, err := decodeJSON([]byte(`{"users": [{"id": 1, "roles": ["admin"]}], "total": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path        string
+		expected    string
+		expectedErr string
+	}{
+		{path: "$", expected: `{"users": [{"id": 1, "roles": ["admin"]}], "total": 1}`},
+		{path: "$.total", expected: `1`},
+		{path: "$.users[0].roles", expected: `["admin"]`},
+		{path: "$.users[0].roles[0]", expected: `"admin"`},
+		{path: "$.users[1].id", expectedErr: "$.users[1] is missing"},
+		{path: "$.users[0].email", expectedErr: "$.users[0].email is missing"},
+		{path: "$.total.count", expectedErr: "$.total is integer, not an object"},
+		{path: "$.users.id", expectedErr: "$.users is array, not an object"},
+		{path: "$.total[0]", expectedErr: "$.total is integer, not an array"},
+		{path: "users", expectedErr: `path "users" must start with $`},
+		{path: "$.users[a]", expectedErr: `path "$.users[a]" has an invalid index "a"`},
+		{path: "$..users", expectedErr: `path "$..users" has an empty key`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			actual, err := jsonPathValue(doc, tt.path)
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Errorf("jsonPathValue() error = %v, expected error %s", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := decodeJSON([]byte(tt.expected))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("jsonPathValue() = %s, expected %s", formatJSON(actual), tt.expected)
+			}
+		})
+	}
+}
+
+func TestJSONEqual(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   string
+		equal    bool
+	}{
+		{expected: `{"id": 1}`, actual: ` { "id" : 1 } `, equal: true},
+		{expected: `{"id": 9007199254740993}`, actual: `{"id": 9007199254740992}`, equal: false},
+		{expected: `{"id": 1}`, actual: `{"id": 1} {"id": 2}`, equal: false},
+		{expected: `{"id": 1}`, actual: `{"id": 1}}`, equal: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.actual, func(t *testing.T) {
+			if equal := jsonEqual([]byte(tt.expected), []byte(tt.actual)); equal != tt.equal {
+				t.Errorf("jsonEqual() = %v, expected %v", equal, tt.equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package httpfake
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// maxFormMemory is the maximum amount of memory used to parse multipart forms
+const maxFormMemory = 32 << 20
+
+// Matcher provides an interface for selecting which request handler responds to an http request.
+// A request handler is only used for the incoming request when all of its matchers match it.
+type Matcher interface {
+	Match(r *http.Request) bool
+}
+
+// MatcherFunc provides a function signature that implements the Matcher interface. This allows for
+// adhoc creation of a custom matcher for use with the Match method.
+type MatcherFunc func(r *http.Request) bool
+
+// Match runs the MatcherFunc against the provided request
+func (m MatcherFunc) Match(r *http.Request) bool {
+	return m(r)
+}
+
+// headerValueMatcher provides a Matcher for a header and its expected value
+type headerValueMatcher struct {
+	Key           string
+	ExpectedValue string
+}
+
+// Match checks if any of the values of the header equals the expected value
+func (h *headerValueMatcher) Match(r *http.Request) bool {
+	return containsString(r.Header[http.CanonicalHeaderKey(h.Key)], h.ExpectedValue)
+}
+
+// String describes the headerValueMatcher
+func (h *headerValueMatcher) String() string {
+	return fmt.Sprintf("header %s=%s", h.Key, h.ExpectedValue)
+}
+
+// queryValueMatcher provides a Matcher for a query parameter and its expected value
+type queryValueMatcher struct {
+	Key           string
+	ExpectedValue string
+}
+
+// Match checks if any of the values of the query parameter equals the expected value
+func (q *queryValueMatcher) Match(r *http.Request) bool {
+	return containsString(r.URL.Query()[q.Key], q.ExpectedValue)
+}
+
+// String describes the queryValueMatcher
+func (q *queryValueMatcher) String() string {
+	return fmt.Sprintf("query %s=%s", q.Key, q.ExpectedValue)
+}
+
+// bodyMatcher provides a Matcher for the exact value of the request body
+type bodyMatcher struct {
+	ExpectedBody []byte
+}
+
+// Match checks if the request body equals the expected body
+func (b *bodyMatcher) Match(r *http.Request) bool {
+	body, err := readBody(r)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(b.ExpectedBody, body)
+}
+
+// String describes the bodyMatcher
+func (b *bodyMatcher) String() string {
+	return fmt.Sprintf("body %s", b.ExpectedBody)
+}
+
+// jsonBodyMatcher provides a Matcher for a JSON request body
+type jsonBodyMatcher struct {
+	ExpectedBody []byte
+	Subset       bool
+}
+
+// Match checks if the JSON request body equals the expected JSON,
+// or only contains it when Subset is set
+func (b *jsonBodyMatcher) Match(r *http.Request) bool {
+	body, err := readBody(r)
+	if err != nil {
+		return false
+	}
+
+	if !b.Subset {
+		return jsonEqual(b.ExpectedBody, body)
+	}
+
+	expected, err := decodeJSON(b.ExpectedBody)
+	if err != nil {
+		return false
+	}
+	actual, err := decodeJSON(body)
+	if err != nil {
+		return false
+	}
+	return jsonContains(expected, actual)
+}
+
+// String describes the jsonBodyMatcher
+func (b *jsonBodyMatcher) String() string {
+	if b.Subset {
+		return fmt.Sprintf("JSON body containing %s", b.ExpectedBody)
+	}
+	return fmt.Sprintf("JSON body %s", b.ExpectedBody)
+}
+
+// formValueMatcher provides a Matcher for a form field of the request body and its expected value
+type formValueMatcher struct {
+	Key           string
+	ExpectedValue string
+}
+
+// Match checks if any of the values of the form field equals the expected value.
+// Both URL encoded and multipart forms are supported.
+func (f *formValueMatcher) Match(r *http.Request) bool {
+	body, err := readBody(r)
+	if err != nil {
+		return false
+	}
+
+	// parse the form from a copy of the request
+	// so the original request is left untouched
+	formReq := *r
+	formReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+	formReq.Form = nil
+	formReq.PostForm = nil
+	formReq.MultipartForm = nil
+	if err := formReq.ParseMultipartForm(maxFormMemory); err != nil && err != http.ErrNotMultipart {
+		return false
+	}
+
+	return containsString(formReq.PostForm[f.Key], f.ExpectedValue)
+}
+
+// String describes the formValueMatcher
+func (f *formValueMatcher) String() string {
+	return fmt.Sprintf("form %s=%s", f.Key, f.ExpectedValue)
+}
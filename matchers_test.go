@@ -0,0 +1,255 @@
+// nolint dupl
+package httpfake
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMatchers_Match(t *testing.T) {
+	tests := []struct {
+		name           string
+		matcher        Matcher
+		requestBuilder func() (*http.Request, error)
+		expected       bool
+	}{
+		{
+			name: "headerValueMatcher should match a request with the expected header value",
+			matcher: &headerValueMatcher{
+				Key:           "accept",
+				ExpectedValue: "application/xml",
+			},
+			requestBuilder: func() (*http.Request, error) {
+				testReq, err := http.NewRequest(http.MethodGet, "http://fake.url", nil)
+				if err != nil {
+					return nil, err
+				}
+
+				testReq.Header.Add("Accept", "application/json")
+				testReq.Header.Add("Accept", "application/xml")
+
+				return testReq, nil
+			},
+			expected: true,
+		},
+		{
+			name: "headerValueMatcher should not match a request with a different header value",
+			matcher: &headerValueMatcher{
+				Key:           "Accept",
+				ExpectedValue: "application/xml",
+			},
+			requestBuilder: func() (*http.Request, error) {
+				testReq, err := http.NewRequest(http.MethodGet, "http://fake.url", nil)
+				if err != nil {
+					return nil, err
+				}
+
+				testReq.Header.Set("Accept", "application/json")
+
+				return testReq, nil
+			},
+			expected: false,
+		},
+		{
+			name: "queryValueMatcher should match a request with the expected query value",
+			matcher: &queryValueMatcher{
+				Key:           "api-version",
+				ExpectedValue: "2",
+			},
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, "http://fake.url?api-version=2", nil)
+			},
+			expected: true,
+		},
+		{
+			name: "queryValueMatcher should not match a request missing the query parameter",
+			matcher: &queryValueMatcher{
+				Key:           "api-version",
+				ExpectedValue: "2",
+			},
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, "http://fake.url?version=2", nil)
+			},
+			expected: false,
+		},
+		{
+			name: "bodyMatcher should match a request with the expected body",
+			matcher: &bodyMatcher{
+				ExpectedBody: []byte(`{"method": "users.get"}`),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPost, "http://fake.url", strings.NewReader(`{"method": "users.get"}`))
+			},
+			expected: true,
+		},
+		{
+			name: "bodyMatcher should not match a request with a different body",
+			matcher: &bodyMatcher{
+				ExpectedBody: []byte(`{"method": "users.get"}`),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPost, "http://fake.url", strings.NewReader(`{"method":"users.get"}`))
+			},
+			expected: false,
+		},
+		{
+			name: "jsonBodyMatcher should match a request with an equivalent JSON body",
+			matcher: &jsonBodyMatcher{
+				ExpectedBody: []byte(`{"method": "users.get", "params": [1, 2]}`),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPost, "http://fake.url",
+					strings.NewReader(`{"params":[1,2],"method":"users.get"}`))
+			},
+			expected: true,
+		},
+		{
+			name: "jsonBodyMatcher should not match a request with extra fields unless it is a subset match",
+			matcher: &jsonBodyMatcher{
+				ExpectedBody: []byte(`{"method": "users.get"}`),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPost, "http://fake.url",
+					strings.NewReader(`{"method": "users.get", "id": 1}`))
+			},
+			expected: false,
+		},
+		{
+			name: "jsonBodyMatcher should not match a request with a different large integer",
+			matcher: &jsonBodyMatcher{
+				ExpectedBody: []byte(`{"jsonrpc": "2.0", "id": 9007199254740993}`),
+			},
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPost, "http://fake.url",
+					strings.NewReader(`{"jsonrpc": "2.0", "id": 9007199254740992}`))
+			},
+			expected: false,
+		},
+		{
+			name: "jsonBodyMatcher should not match a request with a different large integer in the JSON subset",
+			matcher: &jsonBodyMatcher{
+				ExpectedBody: []byte(`{"id": 9007199254740993}`),
+				Subset:       true,
+			},
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPost, "http://fake.url",
+					strings.NewReader(`{"jsonrpc": "2.0", "id": 9007199254740992}`))
+			},
+			expected: false,
+		},
+		{
+			name: "jsonBodyMatcher should match a request containing the expected JSON subset",
+			matcher: &jsonBodyMatcher{
+				ExpectedBody: []byte(`{"method": "users.get", "params": {"ids": [1]}}`),
+				Subset:       true,
+			},
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPost, "http://fake.url",
+					strings.NewReader(`{"jsonrpc": "2.0", "id": 7, "method": "users.get", "params": {"ids": [1, 2]}}`))
+			},
+			expected: true,
+		},
+		{
+			name: "jsonBodyMatcher should not match a request with a different value in the JSON subset",
+			matcher: &jsonBodyMatcher{
+				ExpectedBody: []byte(`{"method": "users.get"}`),
+				Subset:       true,
+			},
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPost, "http://fake.url",
+					strings.NewReader(`{"method": "users.delete"}`))
+			},
+			expected: false,
+		},
+		{
+			name: "formValueMatcher should match a request with the expected url encoded form value",
+			matcher: &formValueMatcher{
+				Key:           "grant_type",
+				ExpectedValue: "client_credentials",
+			},
+			requestBuilder: func() (*http.Request, error) {
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url",
+					strings.NewReader("grant_type=client_credentials&scope=users"))
+				if err != nil {
+					return nil, err
+				}
+
+				testReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+				return testReq, nil
+			},
+			expected: true,
+		},
+		{
+			name: "formValueMatcher should match a request with the expected multipart form value",
+			matcher: &formValueMatcher{
+				Key:           "username",
+				ExpectedValue: "dreamer",
+			},
+			requestBuilder: func() (*http.Request, error) {
+				body := &bytes.Buffer{}
+				writer := multipart.NewWriter(body)
+				if err := writer.WriteField("username", "dreamer"); err != nil {
+					return nil, err
+				}
+				if err := writer.Close(); err != nil {
+					return nil, err
+				}
+
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url", body)
+				if err != nil {
+					return nil, err
+				}
+
+				testReq.Header.Set("Content-Type", writer.FormDataContentType())
+
+				return testReq, nil
+			},
+			expected: true,
+		},
+		{
+			name: "formValueMatcher should not match a request with a different form value",
+			matcher: &formValueMatcher{
+				Key:           "grant_type",
+				ExpectedValue: "client_credentials",
+			},
+			requestBuilder: func() (*http.Request, error) {
+				testReq, err := http.NewRequest(http.MethodPost, "http://fake.url",
+					strings.NewReader("grant_type=password"))
+				if err != nil {
+					return nil, err
+				}
+
+				testReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+				return testReq, nil
+			},
+			expected: false,
+		},
+		{
+			name: "MatcherFunc should execute the custom matcher as expected",
+			matcher: MatcherFunc(func(r *http.Request) bool {
+				return r.Method == http.MethodDelete
+			}),
+			requestBuilder: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodDelete, "http://fake.url", nil)
+			},
+			expected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := tt.requestBuilder()
+			if err != nil {
+				t.Fatalf("error setting up the test request: %s", err.Error())
+			}
+
+			if matched := tt.matcher.Match(req); matched != tt.expected {
+				t.Errorf("Match() returned an unexpected result: got %v want %v", matched, tt.expected)
+			}
+		})
+	}
+}
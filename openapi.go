@@ -0,0 +1,428 @@
+package httpfake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// openAPIMethods are the operations of an OpenAPI path item in the order they are registered
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIParam matches the parameters of an OpenAPI path template, e.g. {id}
+var openAPIParam = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// openAPIDoc is an OpenAPI 3 document
+type openAPIDoc struct {
+	root       map[string]interface{}
+	basePath   string
+	operations []*openAPIOperation
+}
+
+// openAPIOperation is an operation of an OpenAPI document with its $refs resolved
+type openAPIOperation struct {
+	method      string
+	path        string
+	fullPath    string
+	pattern     *regexp.Regexp
+	params      []string
+	parameters  []map[string]interface{}
+	requestBody map[string]interface{}
+	responses   map[string]interface{}
+}
+
+// parseOpenAPI parses an OpenAPI 3 document written in JSON
+func parseOpenAPI(data []byte) (*openAPIDoc, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected an OpenAPI document object")
+	}
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, expected 3.x", version)
+	}
+
+	api := &openAPIDoc{root: root}
+	if err := api.parseBasePath(); err != nil {
+		return nil, err
+	}
+
+	paths, _ := root["paths"].(map[string]interface{})
+	for _, path := range sortedKeys(paths) {
+		item, err := api.resolve(paths[path])
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", path, err)
+		}
+
+		for _, method := range openAPIMethods {
+			if _, ok := item[method]; !ok {
+				continue
+			}
+			op, err := api.parseOperation(item, method, path)
+			if err != nil {
+				return nil, fmt.Errorf("operation %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			api.operations = append(api.operations, op)
+		}
+	}
+
+	return api, nil
+}
+
+// parseBasePath sets the base path of the operations from the URL of the first server
+func (api *openAPIDoc) parseBasePath() error {
+	servers, _ := api.root["servers"].([]interface{})
+	if len(servers) == 0 {
+		return nil
+	}
+
+	server, _ := servers[0].(map[string]interface{})
+	rawURL, _ := server["url"].(string)
+
+	// the server variables are replaced by their default values
+	variables, _ := server["variables"].(map[string]interface{})
+	rawURL = openAPIParam.ReplaceAllStringFunc(rawURL, func(param string) string {
+		variable, _ := variables[param[1:len(param)-1]].(map[string]interface{})
+		value, _ := variable["default"].(string)
+		return value
+	})
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid server URL: %w", err)
+	}
+	api.basePath = strings.TrimSuffix(u.Path, "/")
+	return nil
+}
+
+func (api *openAPIDoc) parseOperation(item map[string]interface{}, method, path string) (*openAPIOperation, error) {
+	operation, err := api.resolve(item[method])
+	if err != nil {
+		return nil, err
+	}
+
+	op := &openAPIOperation{method: strings.ToUpper(method), path: path, fullPath: api.basePath + path}
+	op.pattern, op.params = openAPIPathPattern(op.fullPath)
+
+	// the operation parameters override the path item parameters with the same name and location
+	params := map[string]int{}
+	for _, list := range []interface{}{item["parameters"], operation["parameters"]} {
+		values, _ := list.([]interface{})
+		for _, value := range values {
+			param, err := api.resolve(value)
+			if err != nil {
+				return nil, err
+			}
+			key := fmt.Sprintf("%v:%v", param["in"], param["name"])
+			if i, ok := params[key]; ok {
+				op.parameters[i] = param
+				continue
+			}
+			params[key] = len(op.parameters)
+			op.parameters = append(op.parameters, param)
+		}
+	}
+
+	if body, ok := operation["requestBody"]; ok {
+		if op.requestBody, err = api.resolve(body); err != nil {
+			return nil, err
+		}
+	}
+
+	op.responses, _ = operation["responses"].(map[string]interface{})
+	return op, nil
+}
+
+// resolve returns the object pointed by the $ref of the given node, or the node itself when it has no $ref
+func (api *openAPIDoc) resolve(node interface{}) (map[string]interface{}, error) {
+	return resolveRef(api.root, node)
+}
+
+// resolveRef returns the object pointed by the $ref of the given node within the root document,
+// or the node itself when it has no $ref
+func resolveRef(root, node interface{}) (map[string]interface{}, error) {
+	for i := 0; i < 32; i++ {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object, got %v", node)
+		}
+
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj, nil
+		}
+
+		if node, ok = jsonPointer(root, ref); !ok {
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+	}
+
+	return nil, errors.New("too many nested references")
+}
+
+// jsonPointer returns the value pointed by a local reference, e.g. "#/components/schemas/User"
+func jsonPointer(root interface{}, ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+
+	node := root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return node, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	for _, token := range strings.Split(pointer[1:], "/") {
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+
+		switch value := node.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if node, ok = value[token]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(value) {
+				return nil, false
+			}
+			node = value[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return node, true
+}
+
+// openAPIPathPattern converts a path template to a regular expression matching the paths of the template
+// and returns the names of the parameters of the template in the order they are captured
+func openAPIPathPattern(path string) (*regexp.Regexp, []string) {
+	var pattern strings.Builder
+	var params []string
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range openAPIParam.FindAllStringSubmatchIndex(path, -1) {
+		pattern.WriteString(regexp.QuoteMeta(path[last:loc[0]]))
+		pattern.WriteString("(?P<" + regexpGroupName(path[loc[2]:loc[3]]) + ">[^/]+)")
+		params = append(params, path[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(path[last:]) + "$")
+	return regexp.MustCompile(pattern.String()), params
+}
+
+// matchPath checks if the path matches the path template of the operation
+// and returns the values of the path parameters
+func (op *openAPIOperation) matchPath(path string) (map[string]string, bool) {
+	submatches := op.pattern.FindStringSubmatch(path)
+	if submatches == nil {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, name := range op.params {
+		params[name] = submatches[i+1]
+	}
+	return params, true
+}
+
+// route sets the path template of the operation as the route of a request handler.
+// The templates with parameters taking whole path segments are supported by the path patterns,
+// the other templates, e.g. "/files/{name}.json", are matched by regular expressions.
+func (op *openAPIOperation) route(rh *Request) {
+	for _, segment := range strings.Split(op.fullPath, "/") {
+		if strings.Contains(segment, "{") && !isParamSegment(segment) {
+			rh.methodMatching(op.method, op.pattern, false)
+			return
+		}
+	}
+	rh.method(op.method, op.fullPath)
+}
+
+// regexpGroupName replaces the characters not allowed in the name of a regular expression group
+func regexpGroupName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// successResponse returns the status and the response the fake server replies for the operation:
+// the lowest 2XX response, or the default response, or the first response defined
+func (op *openAPIOperation) successResponse() (int, interface{}) {
+	codes := sortedKeys(op.responses)
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			status, err := strconv.Atoi(code)
+			if err != nil {
+				// a range of status codes, e.g. "2XX"
+				status = 200
+			}
+			return status, op.responses[code]
+		}
+	}
+
+	if res, ok := op.responses["default"]; ok {
+		return 200, res
+	}
+	if len(codes) > 0 {
+		if status, err := strconv.Atoi(codes[0]); err == nil {
+			return status, op.responses[codes[0]]
+		}
+	}
+	return 200, nil
+}
+
+// LoadOpenAPI registers a request handler for every operation of an OpenAPI 3 document written in JSON.
+// The handlers are registered for the operation paths prefixed by the path of the first server URL, with the
+// path templates matched as path parameters. Each handler replies the lowest 2XX response of its operation
+// (or the default response) with the example of its media type, preferring JSON, or with sample data
+// generated from its schema. The generated handlers are not expected to be called by Close.
+// Example:
+//     fakeService.LoadOpenAPI("testdata/petstore.json")
+func (f *HTTPFake) LoadOpenAPI(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	api, err := parseOpenAPI(data)
+	if err != nil {
+		return fmt.Errorf("loading OpenAPI document %s: %w", path, err)
+	}
+
+	handlers := make([]*Request, 0, len(api.operations))
+	for _, op := range api.operations {
+		rh, err := api.handler(op)
+		if err != nil {
+			return fmt.Errorf("loading OpenAPI document %s: operation %s %s: %w", path, op.method, op.path, err)
+		}
+		handlers = append(handlers, rh)
+	}
+
+	f.RequestHandlers = append(f.RequestHandlers, handlers...)
+	return nil
+}
+
+// handler creates the request handler replying the example response of the operation
+func (api *openAPIDoc) handler(op *openAPIOperation) (*Request, error) {
+	rh := NewRequest()
+	op.route(rh)
+	rh.ExpectAtLeast(0)
+
+	status, node := op.successResponse()
+	res := rh.Response
+	res.Status(status)
+	if node == nil {
+		return rh, nil
+	}
+
+	response, err := api.resolve(node)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, _ := response["headers"].(map[string]interface{})
+	for _, name := range sortedKeys(headers) {
+		header, err := api.resolve(headers[name])
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		value, err := api.example(header)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		if value != nil {
+			res.SetHeader(name, fmt.Sprint(value))
+		}
+	}
+
+	content, _ := response["content"].(map[string]interface{})
+	mediaType := preferredMediaType(content)
+	if mediaType == "" {
+		return rh, nil
+	}
+
+	media, err := api.resolve(content[mediaType])
+	if err != nil {
+		return nil, fmt.Errorf("media type %s: %w", mediaType, err)
+	}
+	example, err := api.example(media)
+	if err != nil {
+		return nil, fmt.Errorf("media type %s: %w", mediaType, err)
+	}
+
+	res.SetHeader("Content-Type", mediaType)
+	if text, ok := example.(string); ok && !isJSONMediaType(mediaType) {
+		res.BodyString(text)
+		return rh, nil
+	}
+
+	body, err := json.Marshal(example)
+	if err != nil {
+		return nil, err
+	}
+	res.Body(body)
+	return rh, nil
+}
+
+// example returns the example of a media type, parameter or header object,
+// or sample data generated from its schema when it has no example
+func (api *openAPIDoc) example(obj map[string]interface{}) (interface{}, error) {
+	if example, ok := obj["example"]; ok {
+		return example, nil
+	}
+
+	examples, _ := obj["examples"].(map[string]interface{})
+	if names := sortedKeys(examples); len(names) > 0 {
+		example, err := api.resolve(examples[names[0]])
+		if err != nil {
+			return nil, fmt.Errorf("example %s: %w", names[0], err)
+		}
+		return example["value"], nil
+	}
+
+	schema, ok := obj["schema"]
+	if !ok {
+		return nil, nil
+	}
+	return sampleFromSchema(api.root, schema)
+}
+
+// preferredMediaType returns the JSON media type of the content, or its first media type
+func preferredMediaType(content map[string]interface{}) string {
+	mediaTypes := sortedKeys(content)
+	for _, mediaType := range mediaTypes {
+		if isJSONMediaType(mediaType) {
+			return mediaType
+		}
+	}
+	if len(mediaTypes) > 0 {
+		return mediaTypes[0]
+	}
+	return ""
+}
+
+func isJSONMediaType(mediaType string) bool {
+	mediaType = strings.ToLower(strings.TrimSpace(strings.Split(mediaType, ";")[0]))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
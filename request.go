@@ -1,13 +1,20 @@
 package httpfake
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 	"sync"
 	"testing"
 )
 
+// anyMethod is the method of the request handlers matching any method
+const anyMethod = "*"
+
 // Request stores the settings for a request handler
 // Such as how to match this handler for the incoming requests
 // And how this request will respond back
@@ -18,16 +25,26 @@ type Request struct {
 	Response     *Response
 	CustomHandle Responder
 	assertions   []Assertor
+	matchers     []Matcher
 	called       int
+	calls        []*RecordedRequest
+	notify       chan struct{}
+	limit        int
+	expectation  *callExpectation
+	responses    []*Response
+	route        routeMatcher
 }
 
 // NewRequest creates a new Request
 func NewRequest() *Request {
-	return &Request{
+	r := &Request{
 		URL:      &url.URL{},
 		Response: NewResponse(),
 		called:   0,
 	}
+	r.Response.request = r
+	r.responses = []*Response{r.Response}
+	return r
 }
 
 // Get sets a GET request handler for a given path
@@ -60,6 +77,47 @@ func (r *Request) Head(path string) *Request {
 	return r.method("HEAD", path)
 }
 
+// Any sets a request handler for any method for a given path
+func (r *Request) Any(path string) *Request {
+	return r.method(anyMethod, path)
+}
+
+// GetMatching sets a GET request handler for any path matching the given regular expression
+func (r *Request) GetMatching(pattern *regexp.Regexp) *Request {
+	return r.methodMatching("GET", pattern, false)
+}
+
+// PostMatching sets a POST request handler for any path matching the given regular expression
+func (r *Request) PostMatching(pattern *regexp.Regexp) *Request {
+	return r.methodMatching("POST", pattern, false)
+}
+
+// PutMatching sets a PUT request handler for any path matching the given regular expression
+func (r *Request) PutMatching(pattern *regexp.Regexp) *Request {
+	return r.methodMatching("PUT", pattern, false)
+}
+
+// PatchMatching sets a PATCH request handler for any path matching the given regular expression
+func (r *Request) PatchMatching(pattern *regexp.Regexp) *Request {
+	return r.methodMatching("PATCH", pattern, false)
+}
+
+// DeleteMatching sets a DELETE request handler for any path matching the given regular expression
+func (r *Request) DeleteMatching(pattern *regexp.Regexp) *Request {
+	return r.methodMatching("DELETE", pattern, false)
+}
+
+// HeadMatching sets a HEAD request handler for any path matching the given regular expression
+func (r *Request) HeadMatching(pattern *regexp.Regexp) *Request {
+	return r.methodMatching("HEAD", pattern, false)
+}
+
+// URLMatching sets a request handler for the given method
+// and any URL whose path and query string (e.g. "/users?page=2") match the given regular expression
+func (r *Request) URLMatching(method string, pattern *regexp.Regexp) *Request {
+	return r.methodMatching(method, pattern, true)
+}
+
 // Handle sets a custom handle
 // By setting this responder it gives full control to the user over this request handler
 func (r *Request) Handle(handle Responder) {
@@ -72,16 +130,277 @@ func (r *Request) Reply(status int) *Response {
 	return r.Response.Status(status)
 }
 
+// Times limits this handler to respond only the first n requests.
+// Once the limit is reached the handler is no longer matched, so the requests
+// fall back to the other handlers or are responded as not found.
+// A limited handler is used before the unlimited handlers as specific as it,
+// regardless of the order they are registered.
+func (r *Request) Times(n int) *Request {
+	r.limit = n
+	return r
+}
+
+// Once limits this handler to respond only the first request
+func (r *Request) Once() *Request {
+	return r.Times(1)
+}
+
+// ExpectCalls expects this handler to be called exactly n times
+// The expectation is verified by Close through the testing object set with WithTesting
+func (r *Request) ExpectCalls(n int) *Request {
+	r.expectation = &callExpectation{min: n, max: n}
+	return r
+}
+
+// ExpectAtLeast expects this handler to be called at least n times
+// The expectation is verified by Close through the testing object set with WithTesting
+func (r *Request) ExpectAtLeast(n int) *Request {
+	r.expectation = &callExpectation{min: n, max: -1}
+	return r
+}
+
+// ExpectAtMost expects this handler to be called at most n times
+// The expectation is verified by Close through the testing object set with WithTesting
+func (r *Request) ExpectAtMost(n int) *Request {
+	r.expectation = &callExpectation{min: 0, max: n}
+	return r
+}
+
+// ExpectNever expects this handler to never be called
+// The expectation is verified by Close through the testing object set with WithTesting
+func (r *Request) ExpectNever() *Request {
+	return r.ExpectCalls(0)
+}
+
+// verifyCalls checks if the number of calls to this handler meets its expectation.
+// Without an expectation the handler is expected to be called at least once.
+func (r *Request) verifyCalls() error {
+	r.Lock()
+	called := r.called
+	r.Unlock()
+
+	if r.expectation == nil {
+		if called == 0 {
+			return errors.New("was specified but not called")
+		}
+		return nil
+	}
+
+	return r.expectation.verify(called)
+}
+
+func (r *Request) addCall(recorded *RecordedRequest) int {
+	r.Lock()
+	defer r.Unlock()
+	r.called++
+	r.calls = append(r.calls, recorded)
+	if r.notify != nil {
+		close(r.notify)
+		r.notify = nil
+	}
+	return r.called
+}
+
+func (r *Request) exhausted() bool {
+	r.Lock()
+	defer r.Unlock()
+	return r.limit > 0 && r.called >= r.limit
+}
+
+// responseFor returns the response from the sequence of responses for the given call number.
+// The last response is repeated once the sequence is over.
+func (r *Request) responseFor(call int) *Response {
+	if len(r.responses) < 2 {
+		return r.Response
+	}
+
+	if call < 1 {
+		call = 1
+	}
+	if call > len(r.responses) {
+		call = len(r.responses)
+	}
+	return r.responses[call-1]
+}
+
+// Param returns the value captured for the given path parameter from the incoming request.
+// Path parameters are declared with curly braces in the handler path, e.g. "/users/{id}",
+// and the rest of the path matched by a trailing "*" segment is captured under "*".
+// For handlers set with a regular expression the named capture groups are used instead.
+// Example:
+//     Get("/users/{id}").Handle(func(w http.ResponseWriter, r *http.Request, rh *httpfake.Request) {
+//         id := rh.Param(r, "id")
+//     })
+func (r *Request) Param(testReq *http.Request, name string) string {
+	return r.params(testReq)[name]
+}
+
 func (r *Request) method(method, path string) *Request {
-	if path != "/" {
-		r.URL.Path = path
+	r.URL.Path = path
+	r.Method = strings.ToUpper(method)
+	r.route = nil
+	if pattern := parsePathPattern(path); pattern != nil {
+		r.route = pattern
 	}
+	return r
+}
+
+func (r *Request) methodMatching(method string, pattern *regexp.Regexp, fullURL bool) *Request {
+	r.URL.Path = ""
 	r.Method = strings.ToUpper(method)
+	r.route = &urlPattern{re: pattern, fullURL: fullURL}
+	return r
+}
+
+// matchURL checks how the URL of this handler matches the URL of the incoming request
+func (r *Request) matchURL(u *url.URL) (matchKind, error) {
+	if r.route != nil {
+		if _, ok := r.route.match(u); ok {
+			return r.route.kind(), nil
+		}
+		return noMatch, nil
+	}
+
+	rhURL, err := url.QueryUnescape(r.URL.String())
+	if err != nil {
+		return noMatch, err
+	}
+
+	reqURL := u.String()
+	if rhURL == reqURL {
+		return matchExact, nil
+	}
+
+	if getURLPath(rhURL) != getURLPath(reqURL) {
+		return noMatch, nil
+	}
+
+	// fallback if the income request has query strings
+	// and this handler is only for the path or a subset of the query strings
+	var rawQuery string
+	if i := strings.Index(rhURL, "?"); i >= 0 {
+		rawQuery = rhURL[i+1:]
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return noMatch, err
+	}
+	if !queryContains(u.Query(), query) {
+		return noMatch, nil
+	}
+
+	return matchPath, nil
+}
+
+// describe returns a readable representation of this handler for the error messages
+func (r *Request) describe() string {
+	desc := fmt.Sprintf("[%s: %s]", r.Method, r.path())
+	if len(r.matchers) == 0 {
+		return desc
+	}
+
+	matchers := make([]string, len(r.matchers))
+	for i, matcher := range r.matchers {
+		matchers[i] = "custom matcher"
+		if stringer, ok := matcher.(fmt.Stringer); ok {
+			matchers[i] = stringer.String()
+		}
+	}
+	return desc + " with " + strings.Join(matchers, ", ")
+}
+
+// path returns a readable representation of the path this handler is set for
+func (r *Request) path() string {
+	if r.route != nil {
+		return r.route.String()
+	}
+	return r.URL.Path
+}
+
+// Body returns the body of the incoming request, which is buffered by the fake server
+// so it can be read by every matcher, assertor and responder.
+// Example:
+//     Post("/users").Handle(func(w http.ResponseWriter, r *http.Request, rh *httpfake.Request) {
+//         body := rh.Body(r)
+//     })
+func (r *Request) Body(testReq *http.Request) []byte {
+	body, err := readBody(testReq)
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+func (r *Request) params(testReq *http.Request) map[string]string {
+	if r.route == nil {
+		return nil
+	}
+
+	params, _ := r.route.match(testReq.URL)
+	return params
+}
+
+// Match will only use this handler for the requests accepted by the provided matcher.
+// It allows selecting between handlers set for the same method and path based on anything else in the request.
+func (r *Request) Match(matcher Matcher) *Request {
+	r.matchers = append(r.matchers, matcher)
 	return r
 }
 
+// MatchHeader will only use this handler for the requests with the provided header key and value
+func (r *Request) MatchHeader(key, value string) *Request {
+	return r.Match(&headerValueMatcher{Key: key, ExpectedValue: value})
+}
+
+// MatchQuery will only use this handler for the requests with the provided query parameter and value
+func (r *Request) MatchQuery(key, value string) *Request {
+	return r.Match(&queryValueMatcher{Key: key, ExpectedValue: value})
+}
+
+// MatchBody will only use this handler for the requests with exactly the provided body
+func (r *Request) MatchBody(body []byte) *Request {
+	return r.Match(&bodyMatcher{ExpectedBody: body})
+}
+
+// MatchJSON will only use this handler for the requests with a JSON body holding the same values
+// as the provided JSON, regardless of whitespace and the order of the object keys
+func (r *Request) MatchJSON(body []byte) *Request {
+	if !json.Valid(body) {
+		panic("setup error: \"MatchJSON\" requires a valid JSON document")
+	}
+	return r.Match(&jsonBodyMatcher{ExpectedBody: body})
+}
+
+// MatchJSONSubset will only use this handler for the requests with a JSON body containing the provided JSON.
+// Fields which are not present in the provided JSON are ignored.
+// Example:
+//     MatchJSONSubset([]byte(`{"method": "users.get"}`))
+func (r *Request) MatchJSONSubset(body []byte) *Request {
+	if !json.Valid(body) {
+		panic("setup error: \"MatchJSONSubset\" requires a valid JSON document")
+	}
+	return r.Match(&jsonBodyMatcher{ExpectedBody: body, Subset: true})
+}
+
+// MatchFormValue will only use this handler for the requests with a form body
+// containing the provided field key and value
+func (r *Request) MatchFormValue(key, value string) *Request {
+	return r.Match(&formValueMatcher{Key: key, ExpectedValue: value})
+}
+
+func (r *Request) runMatchers(testReq *http.Request) bool {
+	for _, matcher := range r.matchers {
+		resetBody(testReq)
+		if !matcher.Match(testReq) {
+			return false
+		}
+	}
+	return true
+}
+
 func (r *Request) runAssertions(t testing.TB, testReq *http.Request) {
 	for _, assertor := range r.assertions {
+		resetBody(testReq)
 		assertor.Log(t)
 		if err := assertor.Assert(testReq); err != nil {
 			assertor.Error(t, err)
@@ -119,6 +438,51 @@ func (r *Request) AssertBody(body []byte) *Request {
 	return r
 }
 
+// AssertJSON will assert that the body of the requests to this handler holds the same values as the provided JSON,
+// regardless of whitespace and the order of the object keys. Every value which differs is reported with its path.
+func (r *Request) AssertJSON(body []byte) *Request {
+	if !json.Valid(body) {
+		panic("setup error: \"AssertJSON\" requires a valid JSON document")
+	}
+	r.assertions = append(r.assertions, &requiredJSON{ExpectedBody: body})
+	return r
+}
+
+// AssertJSONSubset will assert that the body of the requests to this handler contains the provided partial JSON document.
+// Every value which differs is reported with its path.
+func (r *Request) AssertJSONSubset(body []byte) *Request {
+	if !json.Valid(body) {
+		panic("setup error: \"AssertJSONSubset\" requires a valid JSON document")
+	}
+	r.assertions = append(r.assertions, &requiredJSON{ExpectedBody: body, Subset: true})
+	return r
+}
+
+// AssertJSONPath will assert that the JSON body of the requests to this handler
+// holds the provided value at the path. The value is compared to the JSON value after being encoded to JSON.
+// Example:
+//     AssertJSONPath("$.users[0].roles", []string{"admin"})
+func (r *Request) AssertJSONPath(path string, value interface{}) *Request {
+	if _, err := splitJSONPath(path); err != nil {
+		panic(fmt.Sprintf("setup error: \"AssertJSONPath\" %v", err))
+	}
+	if _, err := json.Marshal(value); err != nil {
+		panic(fmt.Sprintf("setup error: \"AssertJSONPath\" could not encode the value to JSON: %v", err))
+	}
+	r.assertions = append(r.assertions, &requiredJSONPath{Path: path, ExpectedValue: value})
+	return r
+}
+
+// AssertJSONSchema will assert that the body of the requests to this handler is valid for the provided JSON schema.
+// Every violation of the schema is reported with the path of the invalid value, e.g. "$.users[0].id".
+func (r *Request) AssertJSONSchema(schema []byte) *Request {
+	if !json.Valid(schema) {
+		panic("setup error: \"AssertJSONSchema\" requires a valid JSON schema")
+	}
+	r.assertions = append(r.assertions, &requiredJSONSchema{Schema: schema})
+	return r
+}
+
 // AssertCustom will run the provided assertor against requests to this handler
 func (r *Request) AssertCustom(assertor Assertor) *Request {
 	r.assertions = append(r.assertions, assertor)
@@ -1,21 +1,153 @@
 package httpfake
 
-import "net/http"
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// streamBufferSize is the size of each read from the reader of a streamed response body
+const streamBufferSize = 32 * 1024
 
 // Responder are callbacks to handle the request and write the response
 type Responder func(w http.ResponseWriter, r *http.Request, rh *Request)
 
 // Respond writes the response based in the request handler settings
+// When the request handler has a sequence of responses, the response for the current call is used
 func Respond(w http.ResponseWriter, r *http.Request, rh *Request) {
-	if len(rh.Response.Header) > 0 {
-		for k := range rh.Response.Header {
-			w.Header().Add(k, rh.Response.Header.Get(k))
+	res := rh.responseFor(callNumber(r))
+	if !sleep(r.Context(), res.headerDelay()) {
+		abort(w)
+		return
+	}
+
+	if res.fault != 0 {
+		writeFault(w, r, res)
+		return
+	}
+
+	header, body := res.Header, res.BodyBuffer
+	if res.templated {
+		var err error
+		header, body, err = res.render(r, rh)
+		if err != nil {
+			reportError(r, fmt.Sprintf("error rendering response template for %s: %v", rh.describe(), err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
 	}
-	if rh.Response.StatusCode > 0 {
-		w.WriteHeader(rh.Response.StatusCode)
+
+	if len(header) > 0 {
+		for k := range header {
+			w.Header().Add(k, header.Get(k))
+		}
+	}
+	if res.StatusCode > 0 {
+		w.WriteHeader(res.StatusCode)
+	}
+	if res.bodyDelay > 0 {
+		flush(w)
+		if !sleep(r.Context(), res.bodyDelay) {
+			abort(w)
+			return
+		}
 	}
-	if len(rh.Response.BodyBuffer) > 0 {
-		w.Write(rh.Response.BodyBuffer) // nolint
+
+	switch {
+	case len(res.events) > 0:
+		writeEvents(w, r, res)
+	case len(res.chunks) > 0:
+		writeChunks(w, r, res)
+	case res.bodyReader != nil:
+		writeReader(w, r, res)
+	case len(body) > 0:
+		w.Write(body) // nolint
+	}
+}
+
+// writeChunks writes and flushes each chunk of a streamed response body
+func writeChunks(w http.ResponseWriter, r *http.Request, res *Response) {
+	for i, chunk := range res.chunks {
+		if i > 0 && !sleep(r.Context(), res.chunkDelay) {
+			abort(w)
+			return
+		}
+		if _, err := w.Write(chunk); err != nil {
+			return
+		}
+		flush(w)
+	}
+}
+
+// writeReader writes and flushes each read from the reader of a streamed response body
+func writeReader(w http.ResponseWriter, r *http.Request, res *Response) {
+	reader := res.bodyReader()
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close() // nolint errcheck
+	}
+
+	buf := make([]byte, streamBufferSize)
+	written := false
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if written && !sleep(r.Context(), res.chunkDelay) {
+				abort(w)
+				return
+			}
+			written = true
+			if _, err := w.Write(buf[:n]); err != nil {
+				return
+			}
+			flush(w)
+		}
+		if err != nil {
+			if err != io.EOF {
+				printError(fmt.Sprintf("error reading response body: %v", err))
+			}
+			return
+		}
+	}
+}
+
+// sleep waits for the given duration and returns false
+// if the context is done before that, e.g. when the client disconnects
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// abort closes the connection without completing the response, so the client gets an error
+// instead of the empty 200 OK net/http writes when a handler returns without writing anything
+func abort(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	conn.Close() // nolint errcheck
+}
+
+// flush sends any buffered data to the client, if supported by the response writer
+func flush(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
 	}
 }
@@ -3,7 +3,11 @@ package httpfake
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
+	"text/template"
+	"time"
 )
 
 // Response stores the settings defined by the request handler
@@ -12,6 +16,17 @@ type Response struct {
 	StatusCode int
 	BodyBuffer []byte
 	Header     http.Header
+	request    *Request
+	delayMin   time.Duration
+	delayMax   time.Duration
+	bodyDelay  time.Duration
+	fault      Fault
+	chunks     [][]byte
+	bodyReader func() io.Reader
+	chunkDelay time.Duration
+	events     []Event
+	templated  bool
+	templates  map[string]*template.Template
 }
 
 // NewResponse creates a new Response
@@ -27,21 +42,94 @@ func (r *Response) Status(status int) *Response {
 	return r
 }
 
+// Then adds a new response to the sequence of responses of the request handler,
+// which responds each call with the next response of the sequence and repeats the last one once it is over.
+// It returns the request handler to allow setting the new response with Reply.
+// Example:
+//     Reply(500).Then().Reply(500).Then().Reply(200)
+func (r *Response) Then() *Request {
+	if r.request == nil {
+		panic("setup error: \"Then\" is only supported by the responses created by a request handler")
+	}
+
+	rh := r.request
+	next := NewResponse()
+	next.request = rh
+	rh.responses = append(rh.responses, next)
+	rh.Response = next
+	return rh
+}
+
 // SetHeader sets the a HTTP header to the response
 func (r *Response) SetHeader(key, value string) *Response {
 	r.Header.Set(key, value)
+	r.parseTemplates()
 	return r
 }
 
 // AddHeader adds a HTTP header into the response
 func (r *Response) AddHeader(key, value string) *Response {
 	r.Header.Add(key, value)
+	r.parseTemplates()
+	return r
+}
+
+// Delay sets how long to wait before writing the response headers
+// The wait is aborted and the connection closed as soon as the client disconnects or the fake server is closed.
+func (r *Response) Delay(delay time.Duration) *Response {
+	return r.DelayBetween(delay, delay)
+}
+
+// DelayBetween sets a random wait between min and max before writing the response headers
+// The wait is aborted and the connection closed as soon as the client disconnects or the fake server is closed.
+func (r *Response) DelayBetween(min, max time.Duration) *Response {
+	if max < min {
+		min, max = max, min
+	}
+	r.delayMin = min
+	r.delayMax = max
+	return r
+}
+
+// BodyDelay sets how long to wait after writing the response headers and before writing the response body
+// The wait is aborted and the connection closed as soon as the client disconnects or the fake server is closed.
+func (r *Response) BodyDelay(delay time.Duration) *Response {
+	r.bodyDelay = delay
+	return r
+}
+
+// Fault sets a network fault to be simulated in place of the response
+// Example:
+//     Fault(httpfake.FaultConnectionReset)
+func (r *Response) Fault(fault Fault) *Response {
+	r.fault = fault
+	return r
+}
+
+// headerDelay returns how long to wait before writing the response headers
+func (r *Response) headerDelay() time.Duration {
+	if r.delayMax <= r.delayMin {
+		return r.delayMin
+	}
+	return r.delayMin + time.Duration(rand.Int63n(int64(r.delayMax-r.delayMin)+1))
+}
+
+// Templated renders the response body and header values as Go text/template templates for each request.
+// The templates are executed with a TemplateData built from the incoming request. They are parsed as soon as
+// they are set, so an invalid template panics with a setup error. A key missing from the request body, or from
+// any other map of the TemplateData, fails the response; use index for the optional keys.
+// Example:
+//     Reply(201).Templated().BodyString(`{"id": "{{.Body.id}}", "call": {{.Call}}}`)
+func (r *Response) Templated() *Response {
+	r.templated = true
+	r.parseTemplates()
 	return r
 }
 
 // Body sets the response body from a byte array
 func (r *Response) Body(body []byte) *Response {
 	r.BodyBuffer = body
+	r.parseTemplates()
 	return r
 }
 
@@ -52,6 +140,46 @@ func (r *Response) BodyString(body string) *Response {
 	return r.Body([]byte(body))
 }
 
+// Stream sets the response body as a stream of chunks
+// Each chunk is flushed to the client as soon as it is written, so the body is sent
+// with the chunked transfer encoding.
+// Example:
+//     Stream([]byte(`{"username": "dreamer"}`+"\n"), []byte(`{"username": "sleeper"}`+"\n"))
+func (r *Response) Stream(chunks ...[]byte) *Response {
+	r.chunks = chunks
+	return r
+}
+
+// BodyReader sets the response body as a stream read from a reader created by newReader for each response,
+// so concurrent and repeated requests do not share it. The reader is closed once read when it is an io.Closer.
+// Each read is flushed to the client as soon as it is written, so the body is sent
+// with the chunked transfer encoding.
+// Example:
+//     BodyReader(func() io.Reader { return strings.NewReader(`{"username": "dreamer"}`) })
+func (r *Response) BodyReader(newReader func() io.Reader) *Response {
+	r.bodyReader = newReader
+	return r
+}
+
+// ChunkDelay sets how long to wait between the chunks of a streamed response body
+// The wait is aborted and the connection closed as soon as the client disconnects or the fake server is closed.
+func (r *Response) ChunkDelay(delay time.Duration) *Response {
+	r.chunkDelay = delay
+	return r
+}
+
+// SSE sets the response body as a stream of Server-Sent Events
+// Each event is flushed to the client as soon as it is written, after waiting for its Delay.
+// When the client reconnects with the Last-Event-ID header, only the events after that one are sent.
+// Example:
+//     SSE(httpfake.Event{ID: "1", Event: "user", Data: `{"username": "dreamer"}`})
+func (r *Response) SSE(events ...Event) *Response {
+	r.events = events
+	r.Header.Set("Content-Type", "text/event-stream")
+	r.Header.Set("Cache-Control", "no-cache")
+	return r
+}
+
 // BodyStruct sets the response body from a struct.
 // The provided struct will be marsheled to json internally.
 // Example:
@@ -0,0 +1,185 @@
+package httpfake
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// matchKind describes how the URL of a request handler matches the URL of an incoming request.
+// Higher values are more specific.
+type matchKind int
+
+const (
+	noMatch matchKind = iota
+	matchWildcard
+	matchPattern
+	matchPath
+	matchExact
+)
+
+// routeMatcher matches the URL of an incoming request against a route
+// that is not a plain path and captures the values of its parameters
+type routeMatcher interface {
+	match(u *url.URL) (map[string]string, bool)
+	kind() matchKind
+	String() string
+}
+
+// pathPattern stores a compiled path pattern such as "/users/{id}/*"
+// A segment wrapped in curly braces captures any single path segment under the given name.
+// A "*" segment matches any single path segment, or the rest of the path when it is the last segment.
+// Query parameters set in the pattern are required to be present in the matched URL.
+type pathPattern struct {
+	raw      string
+	segments []string
+	query    url.Values
+	wildcard bool
+}
+
+// parsePathPattern compiles the given path into a pathPattern.
+// It returns nil if the path does not contain any parameter or wildcard segment.
+func parsePathPattern(path string) *pathPattern {
+	raw := path
+	var rawQuery string
+	if i := strings.Index(path, "?"); i >= 0 {
+		path, rawQuery = path[:i], path[i+1:]
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	p := &pathPattern{raw: raw, segments: segments, query: query}
+	isPattern := false
+	for _, segment := range segments {
+		if segment == "*" {
+			p.wildcard = true
+			isPattern = true
+		} else if isParamSegment(segment) {
+			isPattern = true
+		}
+	}
+	if !isPattern {
+		return nil
+	}
+
+	return p
+}
+
+// match checks if the given URL matches the pattern
+// and returns the values captured by the parameter and wildcard segments
+func (p *pathPattern) match(u *url.URL) (map[string]string, bool) {
+	if !queryContains(u.Query(), p.query) {
+		return nil, false
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	params := map[string]string{}
+
+	for i, segment := range p.segments {
+		if i >= len(segments) {
+			return nil, false
+		}
+
+		switch {
+		case segment == "*":
+			if i == len(p.segments)-1 {
+				params["*"] = strings.Join(segments[i:], "/")
+				return params, true
+			}
+			if segments[i] == "" {
+				return nil, false
+			}
+		case isParamSegment(segment):
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[segment[1:len(segment)-1]] = segments[i]
+		case segment != segments[i]:
+			return nil, false
+		}
+	}
+
+	if len(segments) != len(p.segments) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+func (p *pathPattern) kind() matchKind {
+	if p.wildcard {
+		return matchWildcard
+	}
+	return matchPattern
+}
+
+func (p *pathPattern) String() string {
+	return p.raw
+}
+
+// queryContains checks if the query has all the expected parameters and values
+func queryContains(query, expected url.Values) bool {
+	for key, values := range expected {
+		for _, value := range values {
+			if !containsString(query[key], value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isParamSegment(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// urlPattern stores a regular expression matched against the path of the incoming request
+// or, when fullURL is set, against the path followed by the raw query string.
+// Named capture groups are exposed as parameters.
+type urlPattern struct {
+	re      *regexp.Regexp
+	fullURL bool
+}
+
+// match checks if the given URL matches the regular expression
+// and returns the values captured by the named groups
+func (p *urlPattern) match(u *url.URL) (map[string]string, bool) {
+	target := u.Path
+	if p.fullURL && u.RawQuery != "" {
+		target += "?" + u.RawQuery
+	}
+
+	submatches := p.re.FindStringSubmatch(target)
+	if submatches == nil {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, name := range p.re.SubexpNames() {
+		if name != "" {
+			params[name] = submatches[i]
+		}
+	}
+
+	return params, true
+}
+
+func (p *urlPattern) kind() matchKind {
+	return matchWildcard
+}
+
+func (p *urlPattern) String() string {
+	return p.re.String()
+}
@@ -0,0 +1,569 @@
+package httpfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// resolveSchema returns the schema pointed by the $ref of the given schema,
+// or the schema itself when it has no $ref
+func resolveSchema(root, schema interface{}) (map[string]interface{}, error) {
+	// the boolean schemas accept everything (true) or nothing (false)
+	if b, ok := schema.(bool); ok {
+		if b {
+			return map[string]interface{}{}, nil
+		}
+		return map[string]interface{}{"not": map[string]interface{}{}}, nil
+	}
+
+	return resolveRef(root, schema)
+}
+
+// schemaType returns the type of the schema, inferred from its keywords when it is not set
+func schemaType(schema map[string]interface{}) string {
+	switch value := schema["type"].(type) {
+	case string:
+		return value
+	case []interface{}:
+		// a list of types, e.g. ["string", "null"], the first one which is not null is used
+		for _, t := range value {
+			if s, ok := t.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+
+	switch {
+	case schema["properties"] != nil || schema["additionalProperties"] != nil || schema["required"] != nil:
+		return "object"
+	case schema["items"] != nil:
+		return "array"
+	}
+	return ""
+}
+
+// sampleFromSchema generates sample data valid for the given schema.
+// The example, default, const or first enum value of the schema is used when it is set.
+// The properties and items referencing a schema which is already being generated are left out.
+func sampleFromSchema(root, schema interface{}) (interface{}, error) {
+	return sample(root, schema, nil)
+}
+
+// isRecursive checks if the schema references one of the schemas being generated
+func isRecursive(node interface{}, refs []string) bool {
+	obj, _ := node.(map[string]interface{})
+	ref, ok := obj["$ref"].(string)
+	return ok && containsString(refs, ref)
+}
+
+func sample(root, node interface{}, refs []string) (interface{}, error) {
+	if obj, ok := node.(map[string]interface{}); ok {
+		if ref, ok := obj["$ref"].(string); ok {
+			refs = append(refs[:len(refs):len(refs)], ref)
+		}
+	}
+
+	schema, err := resolveSchema(root, node)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, keyword := range []string{"example", "default", "const"} {
+		if value, ok := schema[keyword]; ok {
+			return value, nil
+		}
+	}
+	if enum, _ := schema["enum"].([]interface{}); len(enum) > 0 {
+		return enum[0], nil
+	}
+	if examples, _ := schema["examples"].([]interface{}); len(examples) > 0 {
+		return examples[0], nil
+	}
+
+	if allOf, _ := schema["allOf"].([]interface{}); len(allOf) > 0 {
+		merged := map[string]interface{}{}
+		for _, sub := range allOf {
+			value, err := sample(root, sub, refs)
+			if err != nil {
+				return nil, err
+			}
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return value, nil
+			}
+			for key, v := range obj {
+				merged[key] = v
+			}
+		}
+		// the properties set alongside allOf are merged as well
+		if props, ok := schema["properties"].(map[string]interface{}); ok {
+			value, err := sampleObject(root, props, refs)
+			if err != nil {
+				return nil, err
+			}
+			for key, v := range value {
+				merged[key] = v
+			}
+		}
+		return merged, nil
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if subs, _ := schema[keyword].([]interface{}); len(subs) > 0 {
+			return sample(root, subs[0], refs)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		props, _ := schema["properties"].(map[string]interface{})
+		return sampleObject(root, props, refs)
+	case "array":
+		items, ok := schema["items"]
+		if !ok || isRecursive(items, refs) {
+			return []interface{}{}, nil
+		}
+		item, err := sample(root, items, refs)
+		if err != nil {
+			return nil, err
+		}
+		count := 1
+		if min, ok := schema["minItems"].(float64); ok && int(min) > count {
+			count = int(min)
+		}
+		list := make([]interface{}, count)
+		for i := range list {
+			list[i] = item
+		}
+		return list, nil
+	case "string":
+		return sampleString(schema), nil
+	case "integer":
+		return sampleNumber(schema, 1), nil
+	case "number":
+		return sampleNumber(schema, 0), nil
+	case "boolean":
+		return true, nil
+	default:
+		return nil, nil
+	}
+}
+
+func sampleObject(root interface{}, props map[string]interface{}, refs []string) (map[string]interface{}, error) {
+	obj := map[string]interface{}{}
+	for _, name := range sortedKeys(props) {
+		if isRecursive(props[name], refs) {
+			continue
+		}
+		value, err := sample(root, props[name], refs)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", name, err)
+		}
+		obj[name] = value
+	}
+	return obj, nil
+}
+
+// sampleFormats are the sample values for the string formats
+var sampleFormats = map[string]string{
+	"date":      "2006-01-02",
+	"date-time": "2006-01-02T15:04:05Z",
+	"time":      "15:04:05Z",
+	"email":     "user@example.com",
+	"hostname":  "example.com",
+	"ipv4":      "127.0.0.1",
+	"ipv6":      "::1",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"byte":      "c3RyaW5n",
+	"password":  "password",
+}
+
+func sampleString(schema map[string]interface{}) string {
+	format, _ := schema["format"].(string)
+	value, ok := sampleFormats[format]
+	if !ok {
+		value = "string"
+	}
+
+	if min, ok := schema["minLength"].(float64); ok && len(value) < int(min) {
+		value += strings.Repeat("x", int(min)-len(value))
+	}
+	if max, ok := schema["maxLength"].(float64); ok && len(value) > int(max) {
+		value = value[:int(max)]
+	}
+	return value
+}
+
+// sampleNumber returns the given value, or the closest value allowed by the schema limits
+func sampleNumber(schema map[string]interface{}, value float64) float64 {
+	if min, ok := schema["minimum"].(float64); ok && value < min {
+		value = min
+	}
+	if min, ok := schema["exclusiveMinimum"].(float64); ok && value <= min {
+		value = min + 1
+	}
+	if max, ok := schema["maximum"].(float64); ok && value > max {
+		value = max
+	}
+	if max, ok := schema["exclusiveMaximum"].(float64); ok && value >= max {
+		value = max - 1
+	}
+	return value
+}
+
+// schemaError is a violation of a JSON schema found at the given path of the validated value,
+// e.g. "$.users[0].id"
+type schemaError struct {
+	Path    string
+	Message string
+}
+
+func (e schemaError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// validateSchema validates the value decoded from a JSON document against the given schema
+// and returns all the violations found. The $refs of the schema are resolved within the root document.
+// It supports the JSON Schema keywords used by OpenAPI 3 documents, including "nullable".
+func validateSchema(root, schema, value interface{}) []schemaError {
+	v := &schemaValidator{root: root}
+	v.validate(schema, value, "$")
+	return v.errors
+}
+
+type schemaValidator struct {
+	root   interface{}
+	errors []schemaError
+	// refs are the $refs being followed for each path, so recursive schemas stop
+	refs map[string]bool
+}
+
+func (v *schemaValidator) errorf(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, schemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid checks if the value is valid for the schema without reporting the violations
+func (v *schemaValidator) valid(schema, value interface{}, path string) bool {
+	sub := &schemaValidator{root: v.root, refs: v.refs}
+	sub.validate(schema, value, path)
+	return len(sub.errors) == 0
+}
+
+func (v *schemaValidator) validate(node, value interface{}, path string) {
+	if obj, ok := node.(map[string]interface{}); ok {
+		if ref, ok := obj["$ref"].(string); ok {
+			key := ref + " " + path
+			if v.refs[key] {
+				return
+			}
+			if v.refs == nil {
+				v.refs = map[string]bool{}
+			}
+			v.refs[key] = true
+			defer delete(v.refs, key)
+		}
+	}
+
+	schema, err := resolveSchema(v.root, node)
+	if err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+
+	// the bodies asserted by the JSON assertors are decoded with exact numbers
+	if n, ok := value.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			value = f
+		}
+	}
+
+	if value == nil && schema["nullable"] == true {
+		return
+	}
+
+	if !v.validateType(schema, value, path) {
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsJSON(enum, value) {
+		v.errorf(path, "value %s must be one of %s", formatJSON(value), formatJSON(enum))
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		v.errorf(path, "value %s must be %s", formatJSON(value), formatJSON(constant))
+	}
+
+	switch actual := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(schema, actual, path)
+	case []interface{}:
+		v.validateArray(schema, actual, path)
+	case string:
+		v.validateString(schema, actual, path)
+	case float64:
+		v.validateNumber(schema, actual, path)
+	}
+
+	v.validateComposition(schema, value, path)
+}
+
+// validateType checks the type of the value, it returns false when the type does not match
+func (v *schemaValidator) validateType(schema map[string]interface{}, value interface{}, path string) bool {
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+	default:
+		return true
+	}
+
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+
+	v.errorf(path, "expected %s, got %s", strings.Join(types, " or "), actual)
+	return false
+}
+
+func (v *schemaValidator) validateObject(schema map[string]interface{}, obj map[string]interface{}, path string) {
+	required, _ := schema["required"].([]interface{})
+	for _, name := range required {
+		if s, ok := name.(string); ok {
+			if _, ok := obj[s]; !ok {
+				v.errorf(path, "missing required property %q", s)
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	for _, name := range sortedKeys(obj) {
+		propPath := path + "." + name
+		if prop, ok := props[name]; ok {
+			v.validate(prop, obj[name], propPath)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.errorf(path, "unexpected property %q", name)
+			}
+		case map[string]interface{}:
+			v.validate(additional, obj[name], propPath)
+		}
+	}
+
+	if min, ok := schema["minProperties"].(float64); ok && float64(len(obj)) < min {
+		v.errorf(path, "must have at least %v properties, got %d", min, len(obj))
+	}
+	if max, ok := schema["maxProperties"].(float64); ok && float64(len(obj)) > max {
+		v.errorf(path, "must have at most %v properties, got %d", max, len(obj))
+	}
+}
+
+func (v *schemaValidator) validateArray(schema map[string]interface{}, list []interface{}, path string) {
+	if items, ok := schema["items"]; ok {
+		for i, item := range list {
+			v.validate(items, item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+
+	if min, ok := schema["minItems"].(float64); ok && float64(len(list)) < min {
+		v.errorf(path, "must have at least %v items, got %d", min, len(list))
+	}
+	if max, ok := schema["maxItems"].(float64); ok && float64(len(list)) > max {
+		v.errorf(path, "must have at most %v items, got %d", max, len(list))
+	}
+	if schema["uniqueItems"] == true {
+		for i := range list {
+			if containsJSON(list[:i], list[i]) {
+				v.errorf(path, "must have unique items, item %d is repeated", i)
+				break
+			}
+		}
+	}
+}
+
+// schemaFormats validates the string formats, the unknown formats are accepted
+var schemaFormats = map[string]func(string) bool{
+	"date": func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	},
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		_, err := mail.ParseAddress(s)
+		return err == nil && !strings.ContainsAny(s, "<> ")
+	},
+	"uuid": regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString,
+	"ipv4": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	},
+	"ipv6": func(s string) bool {
+		return net.ParseIP(s) != nil && strings.Contains(s, ":")
+	},
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	},
+}
+
+func (v *schemaValidator) validateString(schema map[string]interface{}, s string, path string) {
+	length := utf8.RuneCountInString(s)
+	if min, ok := schema["minLength"].(float64); ok && float64(length) < min {
+		v.errorf(path, "length must be at least %v, got %d", min, length)
+	}
+	if max, ok := schema["maxLength"].(float64); ok && float64(length) > max {
+		v.errorf(path, "length must be at most %v, got %d", max, length)
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		switch {
+		case err != nil:
+			v.errorf(path, "invalid pattern %s: %v", pattern, err)
+		case !re.MatchString(s):
+			v.errorf(path, "value %q must match pattern %s", s, pattern)
+		}
+	}
+
+	if format, ok := schema["format"].(string); ok {
+		if valid, ok := schemaFormats[format]; ok && !valid(s) {
+			v.errorf(path, "value %q is not a valid %s", s, format)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(schema map[string]interface{}, n float64, path string) {
+	// OpenAPI 3.0 sets the exclusive limits as booleans and JSON Schema as numbers
+	if min, ok := schema["minimum"].(float64); ok {
+		if schema["exclusiveMinimum"] == true && n <= min {
+			v.errorf(path, "must be greater than %v, got %v", min, n)
+		} else if n < min {
+			v.errorf(path, "must be at least %v, got %v", min, n)
+		}
+	}
+	if max, ok := schema["maximum"].(float64); ok {
+		if schema["exclusiveMaximum"] == true && n >= max {
+			v.errorf(path, "must be less than %v, got %v", max, n)
+		} else if n > max {
+			v.errorf(path, "must be at most %v, got %v", max, n)
+		}
+	}
+	if min, ok := schema["exclusiveMinimum"].(float64); ok && n <= min {
+		v.errorf(path, "must be greater than %v, got %v", min, n)
+	}
+	if max, ok := schema["exclusiveMaximum"].(float64); ok && n >= max {
+		v.errorf(path, "must be less than %v, got %v", max, n)
+	}
+	if multiple, ok := schema["multipleOf"].(float64); ok && multiple > 0 {
+		if q := n / multiple; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.errorf(path, "must be a multiple of %v, got %v", multiple, n)
+		}
+	}
+}
+
+func (v *schemaValidator) validateComposition(schema map[string]interface{}, value interface{}, path string) {
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			v.validate(sub, value, path)
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.valid(sub, value, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.errorf(path, "must match at least one schema of anyOf")
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, sub := range oneOf {
+			if v.valid(sub, value, path) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.errorf(path, "must match exactly one schema of oneOf, matched %d", matched)
+		}
+	}
+
+	if not, ok := schema["not"]; ok && v.valid(not, value, path) {
+		v.errorf(path, "must not match the schema of not")
+	}
+}
+
+// jsonType returns the JSON Schema type of a value decoded from a JSON document
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case json.Number:
+		if n, ok := jsonNumber(v); ok && !n.IsInt() {
+			return "number"
+		}
+		return "integer"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func containsJSON(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,161 @@
+package httpfake
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSampleFromSchema(t *testing.T) {
+	root := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(`{
+		"components": {
+			"schemas": {
+				"Node": {"type": "object", "properties": {"name": {"type": "string"}, "child": {"$ref": "#/components/schemas/Node"}}}
+			}
+		}
+	}`), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		schema   string
+		expected string
+	}{
+		{name: "example", schema: `{"type": "string", "example": "dreamer"}`, expected: `"dreamer"`},
+		{name: "enum", schema: `{"type": "string", "enum": ["active", "inactive"]}`, expected: `"active"`},
+		{name: "string format", schema: `{"type": "string", "format": "date"}`, expected: `"2006-01-02"`},
+		{name: "string length", schema: `{"type": "string", "minLength": 8}`, expected: `"stringxx"`},
+		{name: "integer minimum", schema: `{"type": "integer", "minimum": 10}`, expected: `10`},
+		{name: "number maximum", schema: `{"type": "number", "exclusiveMaximum": 0}`, expected: `-1`},
+		{name: "nullable type", schema: `{"type": ["null", "boolean"]}`, expected: `true`},
+		{name: "array", schema: `{"items": {"type": "integer"}, "minItems": 2}`, expected: `[1, 1]`},
+		{
+			name:     "allOf",
+			schema:   `{"allOf": [{"properties": {"id": {"type": "integer"}}}, {"properties": {"name": {"type": "string"}}}]}`,
+			expected: `{"id": 1, "name": "string"}`,
+		},
+		{name: "oneOf", schema: `{"oneOf": [{"type": "boolean"}, {"type": "string"}]}`, expected: `true`},
+		{name: "recursive reference", schema: `{"$ref": "#/components/schemas/Node"}`, expected: `{"name": "string"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema, expected interface{}
+			if err := json.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := sampleFromSchema(root, schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				data, _ := json.Marshal(actual)
+				t.Errorf("sampleFromSchema() = %s, expected %s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	root := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(`{
+		"components": {
+			"schemas": {
+				"User": {
+					"type": "object",
+					"required": ["id", "username"],
+					"additionalProperties": false,
+					"properties": {
+						"id": {"type": "integer", "minimum": 1},
+						"username": {"type": "string", "minLength": 3, "pattern": "^[a-z]+$"},
+						"email": {"type": "string", "format": "email", "nullable": true},
+						"roles": {"type": "array", "items": {"enum": ["admin", "user"]}, "uniqueItems": true},
+						"manager": {"$ref": "#/components/schemas/User"}
+					}
+				}
+			}
+		}
+	}`), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		schema   string
+		value    string
+		expected []string
+	}{
+		{
+			name:     "valid object",
+			schema:   `{"$ref": "#/components/schemas/User"}`,
+			value:    `{"id": 1, "username": "dreamer", "email": null, "roles": ["admin"], "manager": {"id": 2, "username": "sleeper"}}`,
+			expected: nil,
+		},
+		{
+			name:   "invalid object",
+			schema: `{"$ref": "#/components/schemas/User"}`,
+			value:  `{"id": 0, "username": "Dr", "email": "dreamer", "roles": ["user", "guest", "user"], "manager": {"id": 1.5}, "age": 30}`,
+			expected: []string{
+				`$: unexpected property "age"`,
+				`$.email: value "dreamer" is not a valid email`,
+				`$.id: must be at least 1, got 0`,
+				`$.manager: missing required property "username"`,
+				`$.manager.id: expected integer, got number`,
+				`$.roles[1]: value "guest" must be one of ["admin","user"]`,
+				`$.roles: must have unique items, item 2 is repeated`,
+				`$.username: length must be at least 3, got 2`,
+				`$.username: value "Dr" must match pattern ^[a-z]+$`,
+			},
+		},
+		{
+			name:     "type",
+			schema:   `{"type": ["string", "null"]}`,
+			value:    `10`,
+			expected: []string{`$: expected string or null, got integer`},
+		},
+		{
+			name:     "exclusive limits",
+			schema:   `{"type": "number", "minimum": 0, "exclusiveMinimum": true, "exclusiveMaximum": 10, "multipleOf": 0.5}`,
+			value:    `0`,
+			expected: []string{`$: must be greater than 0, got 0`},
+		},
+		{
+			name:     "composition",
+			schema:   `{"oneOf": [{"type": "integer"}, {"type": "number"}], "not": {"const": 3}}`,
+			value:    `3`,
+			expected: []string{`$: must match exactly one schema of oneOf, matched 2`, `$: must not match the schema of not`},
+		},
+		{
+			name:     "anyOf",
+			schema:   `{"anyOf": [{"type": "string"}, {"type": "array", "maxItems": 1}]}`,
+			value:    `[1, 2]`,
+			expected: []string{`$: must match at least one schema of anyOf`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema, value interface{}
+			if err := json.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatal(err)
+			}
+
+			var actual []string
+			for _, err := range validateSchema(root, schema, value) {
+				actual = append(actual, err.Error())
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("validateSchema() = %q, expected %q", actual, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package httpfake
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Event stores a Server-Sent Event sent by a response set with SSE
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	// Retry sets the client reconnection time
+	Retry time.Duration
+	// Delay sets how long to wait before sending the event, after the previous one was sent
+	Delay time.Duration
+}
+
+// write writes the event in the text/event-stream format
+func (e Event) write(w io.Writer) error {
+	var b strings.Builder
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", e.ID)
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", e.Event)
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry/time.Millisecond)
+	}
+	for _, line := range strings.Split(e.Data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeEvents writes and flushes each event of a Server-Sent Events response.
+// When the client reconnects with the Last-Event-ID header,
+// only the events after the one with that ID are sent.
+func writeEvents(w http.ResponseWriter, r *http.Request, res *Response) {
+	events := res.events
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		for i, event := range events {
+			if event.ID == lastEventID {
+				events = events[i+1:]
+				break
+			}
+		}
+	}
+
+	for _, event := range events {
+		if !sleep(r.Context(), event.Delay) {
+			abort(w)
+			return
+		}
+		if err := event.write(w); err != nil {
+			return
+		}
+		flush(w)
+	}
+}
@@ -0,0 +1,148 @@
+package httpfake
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"text/template"
+)
+
+// TemplateData is the data available to the templates of a templated response
+// Example:
+//     BodyString(`{"id": "{{.Params.id}}", "name": "{{.Body.name}}", "page": "{{.Query.Get "page"}}"}`)
+type TemplateData struct {
+	Method string
+	URL    *url.URL
+	Path   string
+	// Params stores the values captured for the path parameters, see Request.Param
+	Params map[string]string
+	Query  url.Values
+	Header http.Header
+	// Body stores the request body decoded from JSON, with the numbers kept as json.Number,
+	// or an empty map if the body is empty or not valid JSON
+	Body interface{}
+	// RawBody stores the request body as it was received
+	RawBody string
+	// Call is the number of the call to the request handler, starting at 1
+	Call int
+}
+
+// newTemplateData builds the template data from the incoming request
+func newTemplateData(r *http.Request, rh *Request) (*TemplateData, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &TemplateData{
+		Method:  r.Method,
+		URL:     r.URL,
+		Path:    r.URL.Path,
+		Params:  rh.params(r),
+		Query:   r.URL.Query(),
+		Header:  r.Header,
+		Body:    map[string]interface{}{},
+		RawBody: string(body),
+		Call:    callNumber(r),
+	}
+	if data.Params == nil {
+		data.Params = map[string]string{}
+	}
+	if value, err := decodeJSON(body); err == nil && value != nil {
+		data.Body = value
+	}
+
+	return data, nil
+}
+
+// render renders the response headers and body templates for the incoming request
+func (r *Response) render(req *http.Request, rh *Request) (http.Header, []byte, error) {
+	data, err := newTemplateData(req, rh)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := make(http.Header, len(r.Header))
+	for key, values := range r.Header {
+		for _, value := range values {
+			rendered, err := r.renderTemplate(key, value, data)
+			if err != nil {
+				return nil, nil, err
+			}
+			header.Add(key, string(rendered))
+		}
+	}
+
+	body, err := r.renderTemplate("body", string(r.BodyBuffer), data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return header, body, nil
+}
+
+// parseTemplates parses the header and body templates of a templated response when it is set up,
+// so an invalid template is reported as a setup error instead of failing every request
+func (r *Response) parseTemplates() {
+	if !r.templated {
+		return
+	}
+
+	templates, err := parseResponseTemplates(r.Header, r.BodyBuffer)
+	if err != nil {
+		panic(fmt.Sprintf("setup error: \"Templated\" response has an invalid template: %v", err))
+	}
+	r.templates = templates
+}
+
+// parseResponseTemplates parses the header values and the body of a response as templates, by their text
+func parseResponseTemplates(header http.Header, body []byte) (map[string]*template.Template, error) {
+	templates := map[string]*template.Template{}
+	parse := func(name, text string) error {
+		if _, ok := templates[text]; ok {
+			return nil
+		}
+		tmpl, err := newTemplate(name, text)
+		if err != nil {
+			return err
+		}
+		templates[text] = tmpl
+		return nil
+	}
+
+	for _, key := range sortedKeys(header) {
+		for _, value := range header[key] {
+			if err := parse(key, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := parse("body", string(body)); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// newTemplate parses a response template, the keys missing from the maps of the
+// template data, such as the fields of the request body, fail the rendering
+func newTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
+
+func (r *Response) renderTemplate(name, text string, data *TemplateData) ([]byte, error) {
+	tmpl, ok := r.templates[text]
+	if !ok {
+		// the header or body was changed through the exported fields after the response was set up
+		var err error
+		if tmpl, err = newTemplate(name, text); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
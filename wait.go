@@ -0,0 +1,70 @@
+package httpfake
+
+import (
+	"context"
+	"fmt"
+)
+
+// WaitForCalls blocks until this request handler has been called at least n times
+// or the context is done, in which case the context error is returned.
+// It returns as soon as the requests are received, which may be before they are responded.
+func (r *Request) WaitForCalls(ctx context.Context, n int) error {
+	for {
+		r.Lock()
+		called := r.called
+		if called >= n {
+			r.Unlock()
+			return nil
+		}
+		if r.notify == nil {
+			r.notify = make(chan struct{})
+		}
+		notify := r.notify
+		r.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %s to be called %s, it was called %s: %w",
+				r.describe(), pluralizeTimes(n), pluralizeTimes(called), ctx.Err())
+		}
+	}
+}
+
+// WaitFor blocks until the predicate returns true for the requests received by the fake server
+// or the context is done, in which case the context error is returned.
+// The predicate is called with the recorded requests every time a new request is received.
+// Example:
+//     fakeService.WaitFor(ctx, func(requests []*httpfake.RecordedRequest) bool {
+//         return len(requests) >= 3
+//     })
+func (f *HTTPFake) WaitFor(ctx context.Context, predicate func(requests []*RecordedRequest) bool) error {
+	for {
+		f.mu.Lock()
+		requests := make([]*RecordedRequest, len(f.journal))
+		copy(requests, f.journal)
+		if f.notify == nil {
+			f.notify = make(chan struct{})
+		}
+		notify := f.notify
+		f.mu.Unlock()
+
+		if predicate(requests) {
+			return nil
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for the fake server requests after %s: %w",
+				pluralizeRequests(len(requests)), ctx.Err())
+		}
+	}
+}
+
+func pluralizeRequests(n int) string {
+	if n == 1 {
+		return "1 request"
+	}
+	return fmt.Sprintf("%d requests", n)
+}
@@ -0,0 +1,424 @@
+package httpfake
+
+import (
+	"bufio"
+	"crypto/sha1" // nolint gas
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebSocket message types
+const (
+	WebSocketText   = 1
+	WebSocketBinary = 2
+)
+
+// WebSocket close codes
+const (
+	WebSocketCloseNormal          = 1000
+	WebSocketCloseGoingAway       = 1001
+	WebSocketCloseProtocolError   = 1002
+	WebSocketCloseUnsupportedData = 1003
+	WebSocketCloseInternalError   = 1011
+)
+
+const (
+	webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	wsOpContinuation = 0
+	wsOpClose        = 8
+	wsOpPing         = 9
+	wsOpPong         = 10
+
+	// wsMaxMessageSize is the maximum size of a message received from the client
+	wsMaxMessageSize = 32 << 20
+	// wsCloseTimeout is how long to wait for the client to reply a close frame
+	wsCloseTimeout = time.Second
+)
+
+// WebSocketHandler provides a function signature for scripting the conversation with the client
+// of a WebSocket request handler. A returned error fails the test through the testing object
+// set with WithTesting.
+type WebSocketHandler func(conn *WebSocketConn, r *http.Request) error
+
+// WebSocketMessage stores a message received from the client of a WebSocket request handler
+type WebSocketMessage struct {
+	Type int
+	Data []byte
+}
+
+// Text returns the message data as a string
+func (m *WebSocketMessage) Text() string {
+	return string(m.Data)
+}
+
+// WebSocketCloseError is returned when reading from a connection closed by the client
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *WebSocketCloseError) Error() string {
+	return fmt.Sprintf("websocket connection closed by the client with code %d %s", e.Code, e.Reason)
+}
+
+// WebSocketMatcher provides a function signature for checking a message received from the client.
+// It returns an error describing why the message does not match.
+type WebSocketMatcher func(msg *WebSocketMessage) error
+
+// MessageText matches a text message with exactly the given text
+func MessageText(text string) WebSocketMatcher {
+	return func(msg *WebSocketMessage) error {
+		if msg.Type != WebSocketText || msg.Text() != text {
+			return fmt.Errorf("message does not have the expected value; expected %s to equal %s", msg.Text(), text)
+		}
+		return nil
+	}
+}
+
+// MessageMatching matches a message whose data matches the given regular expression
+func MessageMatching(pattern *regexp.Regexp) WebSocketMatcher {
+	return func(msg *WebSocketMessage) error {
+		if !pattern.Match(msg.Data) {
+			return fmt.Errorf("message does not have the expected value; expected %s to match %s", msg.Text(), pattern)
+		}
+		return nil
+	}
+}
+
+// MessageJSON matches a message with a JSON document holding the same values as the given JSON,
+// regardless of whitespace and the order of the object keys
+func MessageJSON(body []byte) WebSocketMatcher {
+	return func(msg *WebSocketMessage) error {
+		if !jsonEqual(body, msg.Data) {
+			return fmt.Errorf("message does not have the expected value; expected %s to equal %s", msg.Text(), body)
+		}
+		return nil
+	}
+}
+
+// MessageJSONSubset matches a message with a JSON document containing the given JSON
+func MessageJSONSubset(body []byte) WebSocketMatcher {
+	return func(msg *WebSocketMessage) error {
+		expected, err := decodeJSON(body)
+		if err != nil {
+			return fmt.Errorf("invalid expected JSON %s: %v", body, err)
+		}
+		if actual, err := decodeJSON(msg.Data); err != nil || !jsonContains(expected, actual) {
+			return fmt.Errorf("message does not have the expected value; expected %s to contain %s", msg.Text(), body)
+		}
+		return nil
+	}
+}
+
+// WebSocket upgrades the requests to this handler to WebSocket connections
+// and runs the given handler to script the conversation with the client.
+// Example:
+//     WebSocket(func(conn *httpfake.WebSocketConn, r *http.Request) error {
+//         if _, err := conn.Expect(httpfake.MessageText("ping")); err != nil {
+//             return err
+//         }
+//         return conn.SendText("pong")
+//     })
+func (r *Request) WebSocket(handler WebSocketHandler) *Request {
+	r.CustomHandle = func(w http.ResponseWriter, req *http.Request, rh *Request) {
+		conn, err := upgradeWebSocket(w, req)
+		if err != nil {
+			reportError(req, fmt.Sprintf("error upgrading %s to websocket: %v", rh.describe(), err))
+			return
+		}
+		defer conn.Close(WebSocketCloseNormal, "") // nolint errcheck
+
+		// close the connection when the fake server is closed
+		// so the handler is not blocked reading from the client forever
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-req.Context().Done():
+				conn.conn.Close() // nolint errcheck
+			case <-done:
+			}
+		}()
+
+		if err := handler(conn, req); err != nil {
+			reportError(req, fmt.Sprintf("websocket handler %s: %v", rh.describe(), err))
+		}
+	}
+	return r
+}
+
+// WebSocketConn is the server side of a WebSocket connection to the fake server
+type WebSocketConn struct {
+	conn   net.Conn
+	buf    *bufio.ReadWriter
+	mu     sync.Mutex
+	closed bool
+}
+
+// upgradeWebSocket runs the WebSocket opening handshake and hijacks the connection
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*WebSocketConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, errors.New("the request is not a valid websocket handshake")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, errors.New("the response writer does not support hijacking the connection")
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum([]byte(key + webSocketGUID)) // nolint gas
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n\r\n") // nolint errcheck
+	if err := buf.Flush(); err != nil {
+		conn.Close() // nolint errcheck
+		return nil, err
+	}
+
+	return &WebSocketConn{conn: conn, buf: buf}, nil
+}
+
+// ReadMessage reads the next message sent by the client
+// Ping frames are answered automatically. When the client closes the connection
+// the close is acknowledged and a *WebSocketCloseError is returned.
+func (c *WebSocketConn) ReadMessage() (*WebSocketMessage, error) {
+	var msg *WebSocketMessage
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+		case wsOpPong:
+		case wsOpClose:
+			closeErr := &WebSocketCloseError{Code: WebSocketCloseNormal}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.mu.Lock()
+			if !c.closed {
+				c.closed = true
+				c.writeFrameLocked(wsOpClose, payload) // nolint errcheck
+			}
+			c.mu.Unlock()
+			c.conn.Close() // nolint errcheck
+			return nil, closeErr
+		case wsOpContinuation:
+			if msg == nil {
+				return nil, c.fail("unexpected continuation frame")
+			}
+			if len(msg.Data)+len(payload) > wsMaxMessageSize {
+				return nil, c.fail("message too large")
+			}
+			msg.Data = append(msg.Data, payload...)
+		case WebSocketText, WebSocketBinary:
+			if msg != nil {
+				return nil, c.fail("expected a continuation frame")
+			}
+			msg = &WebSocketMessage{Type: int(opcode), Data: payload}
+		default:
+			return nil, c.fail(fmt.Sprintf("unknown opcode %d", opcode))
+		}
+
+		if msg != nil && fin {
+			return msg, nil
+		}
+	}
+}
+
+// Expect reads the next message sent by the client
+// and returns an error if any of the matchers does not match it
+func (c *WebSocketConn) Expect(matchers ...WebSocketMatcher) (*WebSocketMessage, error) {
+	msg, err := c.ReadMessage()
+	if err != nil {
+		return nil, fmt.Errorf("expected a message from the client: %v", err)
+	}
+
+	for _, matcher := range matchers {
+		if err := matcher(msg); err != nil {
+			return msg, err
+		}
+	}
+
+	return msg, nil
+}
+
+// Send sends a message of the given type (WebSocketText or WebSocketBinary) to the client
+func (c *WebSocketConn) Send(messageType int, data []byte) error {
+	return c.writeFrame(byte(messageType), data)
+}
+
+// SendText sends a text message to the client
+func (c *WebSocketConn) SendText(text string) error {
+	return c.Send(WebSocketText, []byte(text))
+}
+
+// SendJSON sends the given value marshaled to JSON as a text message to the client
+func (c *WebSocketConn) SendJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Send(WebSocketText, data)
+}
+
+// Close sends a close frame with the given code and reason to the client,
+// waits for the client to acknowledge it and closes the connection
+func (c *WebSocketConn) Close(code int, reason string) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], reason)
+	err := c.writeFrameLocked(wsOpClose, payload)
+	c.mu.Unlock()
+
+	if err == nil {
+		// wait for the client close frame, discarding any other message
+		c.conn.SetReadDeadline(time.Now().Add(wsCloseTimeout)) // nolint errcheck
+		for {
+			_, opcode, _, readErr := c.readFrame()
+			if readErr != nil || opcode == wsOpClose {
+				break
+			}
+		}
+	}
+
+	c.conn.Close() // nolint errcheck
+	return err
+}
+
+// fail closes the connection because of a protocol error
+func (c *WebSocketConn) fail(reason string) error {
+	c.Close(WebSocketCloseProtocolError, reason) // nolint errcheck
+	return errors.New("websocket protocol error: " + reason)
+}
+
+// readFrame reads a single frame sent by the client
+func (c *WebSocketConn) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.buf, header); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.buf, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.buf, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+
+	if !masked {
+		return false, 0, nil, errors.New("websocket protocol error: client frames must be masked")
+	}
+	if length > wsMaxMessageSize {
+		return false, 0, nil, errors.New("websocket protocol error: frame too large")
+	}
+
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(c.buf, mask); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.buf, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+func (c *WebSocketConn) writeFrame(opcode byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errors.New("websocket connection is closed")
+	}
+	return c.writeFrameLocked(opcode, payload)
+}
+
+// writeFrameLocked writes a single unmasked frame, the caller must hold the connection lock
+func (c *WebSocketConn) writeFrameLocked(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	length := len(payload)
+	switch {
+	case length < 126:
+		header = append(header, byte(length))
+	case length <= 0xffff:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+
+	if _, err := c.buf.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.buf.Write(payload); err != nil {
+		return err
+	}
+	return c.buf.Flush()
+}
+
+// headerContainsToken checks if the comma separated values of the header contain the given token
+func headerContainsToken(header http.Header, key, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(key)] {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,385 @@
+package httpfake
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// wireMockMapping is a WireMock stub mapping
+type wireMockMapping struct {
+	Request  wireMockRequest  `json:"request"`
+	Response wireMockResponse `json:"response"`
+}
+
+type wireMockRequest struct {
+	Method          string                     `json:"method"`
+	URL             string                     `json:"url"`
+	URLPath         string                     `json:"urlPath"`
+	URLPattern      string                     `json:"urlPattern"`
+	URLPathPattern  string                     `json:"urlPathPattern"`
+	Headers         map[string]json.RawMessage `json:"headers"`
+	QueryParameters map[string]json.RawMessage `json:"queryParameters"`
+	BodyPatterns    []json.RawMessage          `json:"bodyPatterns"`
+}
+
+type wireMockResponse struct {
+	Status                 int                        `json:"status"`
+	Headers                map[string]json.RawMessage `json:"headers"`
+	Body                   *string                    `json:"body"`
+	JSONBody               json.RawMessage            `json:"jsonBody"`
+	Base64Body             string                     `json:"base64Body"`
+	BodyFileName           string                     `json:"bodyFileName"`
+	FixedDelayMilliseconds int                        `json:"fixedDelayMilliseconds"`
+	Fault                  string                     `json:"fault"`
+}
+
+// wireMockPattern is a WireMock value pattern, e.g. {"equalTo": "application/json"}
+type wireMockPattern struct {
+	EqualTo             *string         `json:"equalTo"`
+	CaseInsensitive     bool            `json:"caseInsensitive"`
+	Contains            *string         `json:"contains"`
+	Matches             *string         `json:"matches"`
+	DoesNotMatch        *string         `json:"doesNotMatch"`
+	Absent              bool            `json:"absent"`
+	EqualToJSON         json.RawMessage `json:"equalToJson"`
+	IgnoreExtraElements bool            `json:"ignoreExtraElements"`
+	IgnoreArrayOrder    bool            `json:"ignoreArrayOrder"`
+	re                  *regexp.Regexp
+	expectedJSON        interface{}
+}
+
+// wireMockFaults maps the WireMock faults to the faults simulated by the fake server
+var wireMockFaults = map[string]Fault{
+	"EMPTY_RESPONSE":           FaultEmptyResponse,
+	"CONNECTION_RESET_BY_PEER": FaultConnectionReset,
+	"MALFORMED_RESPONSE_CHUNK": FaultTruncatedBody,
+	"RANDOM_DATA_THEN_CLOSE":   FaultMalformedResponse,
+}
+
+// LoadWireMock registers the request handlers defined by the WireMock stub mappings in the "mappings"
+// folder of the given directory. The body files set with "bodyFileName" are read from the "__files" folder.
+// The requests are matched by method (or "ANY"), url, urlPath, urlPattern or urlPathPattern, by headers and
+// query parameters with the equalTo, contains, matches, doesNotMatch and absent patterns and by bodyPatterns
+// with the equalTo, contains, matches and equalToJson patterns.
+// The response supports status, headers, body, jsonBody, base64Body, bodyFileName, fixedDelayMilliseconds and fault.
+// Mapping priorities, scenarios and response templates are not supported.
+// The handlers are not expected to be called by Close.
+// Example:
+//     fakeService.LoadWireMock("testdata/wiremock")
+func (f *HTTPFake) LoadWireMock(dir string) error {
+	names, err := filepath.Glob(filepath.Join(dir, "mappings", "*.json"))
+	if err != nil {
+		return err
+	}
+
+	files := filepath.Join(dir, "__files")
+	return f.loadWireMock(names, ioutil.ReadFile, func(name string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(files, filepath.FromSlash(name)))
+	})
+}
+
+// loadWireMock registers the request handlers defined by the given mapping files.
+// The mapping files are read with readFile and the body files with readBodyFile.
+func (f *HTTPFake) loadWireMock(names []string, readFile, readBodyFile func(name string) ([]byte, error)) error {
+	var handlers []*Request
+	for _, name := range names {
+		data, err := readFile(name)
+		if err != nil {
+			return err
+		}
+
+		mappings, err := decodeWireMockMappings(data)
+		if err != nil {
+			return fmt.Errorf("loading wiremock mapping %s: %w", name, err)
+		}
+
+		for i, mapping := range mappings {
+			rh, err := mapping.handler(readBodyFile)
+			if err != nil {
+				return fmt.Errorf("loading wiremock mapping %s: mapping %d: %w", name, i, err)
+			}
+			handlers = append(handlers, rh)
+		}
+	}
+
+	f.RequestHandlers = append(f.RequestHandlers, handlers...)
+	return nil
+}
+
+// decodeWireMockMappings decodes a single mapping or a list of mappings under "mappings"
+func decodeWireMockMappings(data []byte) ([]wireMockMapping, error) {
+	var doc struct {
+		wireMockMapping
+		Mappings []wireMockMapping `json:"mappings"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc.Mappings != nil {
+		return doc.Mappings, nil
+	}
+	return []wireMockMapping{doc.wireMockMapping}, nil
+}
+
+// handler creates the request handler set by the mapping
+func (m *wireMockMapping) handler(readBodyFile func(name string) ([]byte, error)) (*Request, error) {
+	req := m.Request
+	method := req.Method
+	if method == "" || strings.EqualFold(method, "ANY") {
+		method = anyMethod
+	}
+
+	rh := NewRequest()
+	// the imported stubs are not expected to be called
+	rh.ExpectAtLeast(0)
+	switch {
+	case req.URL != "":
+		// WireMock urls must equal the whole path and query string
+		rh.methodMatching(method, regexp.MustCompile("^"+regexp.QuoteMeta(req.URL)+"$"), true)
+	case req.URLPath != "":
+		rh.method(method, req.URLPath)
+	case req.URLPattern != "" || req.URLPathPattern != "":
+		pattern := req.URLPattern
+		if pattern == "" {
+			pattern = req.URLPathPattern
+		}
+		// WireMock patterns must match the whole URL
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid url pattern: %w", err)
+		}
+		rh.methodMatching(method, re, req.URLPattern != "")
+	default:
+		rh.methodMatching(method, regexp.MustCompile(""), false)
+	}
+
+	for _, key := range sortedKeys(req.Headers) {
+		pattern, err := decodeWireMockPattern(req.Headers[key])
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", key, err)
+		}
+		rh.Match(&wireMockMatcher{Source: "header", Key: key, Pattern: pattern})
+	}
+
+	for _, key := range sortedKeys(req.QueryParameters) {
+		pattern, err := decodeWireMockPattern(req.QueryParameters[key])
+		if err != nil {
+			return nil, fmt.Errorf("query parameter %s: %w", key, err)
+		}
+		rh.Match(&wireMockMatcher{Source: "query", Key: key, Pattern: pattern})
+	}
+
+	for i, data := range req.BodyPatterns {
+		pattern, err := decodeWireMockPattern(data)
+		if err != nil {
+			return nil, fmt.Errorf("body pattern %d: %w", i, err)
+		}
+		rh.Match(&wireMockMatcher{Source: "body", Pattern: pattern})
+	}
+
+	if err := m.Response.apply(rh.Response, readBodyFile); err != nil {
+		return nil, err
+	}
+
+	return rh, nil
+}
+
+func (r *wireMockResponse) apply(res *Response, readBodyFile func(name string) ([]byte, error)) error {
+	status := r.Status
+	if status == 0 {
+		status = 200
+	}
+	res.Status(status)
+
+	for _, key := range sortedKeys(r.Headers) {
+		var values []string
+		if err := json.Unmarshal(r.Headers[key], &values); err != nil {
+			var value string
+			if err := json.Unmarshal(r.Headers[key], &value); err != nil {
+				return fmt.Errorf("response header %s: expected a string or a list of strings", key)
+			}
+			values = []string{value}
+		}
+		for _, value := range values {
+			res.AddHeader(key, value)
+		}
+	}
+
+	switch {
+	case r.Body != nil:
+		res.BodyString(*r.Body)
+	case r.JSONBody != nil:
+		res.Body(r.JSONBody)
+	case r.Base64Body != "":
+		body, err := base64.StdEncoding.DecodeString(r.Base64Body)
+		if err != nil {
+			return fmt.Errorf("invalid base64Body: %w", err)
+		}
+		res.Body(body)
+	case r.BodyFileName != "":
+		body, err := readBodyFile(r.BodyFileName)
+		if err != nil {
+			return err
+		}
+		res.Body(body)
+	}
+
+	if r.FixedDelayMilliseconds > 0 {
+		res.Delay(time.Duration(r.FixedDelayMilliseconds) * time.Millisecond)
+	}
+
+	if r.Fault != "" {
+		fault, ok := wireMockFaults[r.Fault]
+		if !ok {
+			return fmt.Errorf("unsupported fault %s", r.Fault)
+		}
+		res.Fault(fault)
+	}
+
+	return nil
+}
+
+// decodeWireMockPattern decodes a value pattern, failing for the patterns that are not supported
+func decodeWireMockPattern(data json.RawMessage) (wireMockPattern, error) {
+	var pattern wireMockPattern
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&pattern); err != nil {
+		return pattern, fmt.Errorf("unsupported pattern %s: %w", data, err)
+	}
+
+	switch {
+	case pattern.Matches != nil:
+		re, err := regexp.Compile("^(?:" + *pattern.Matches + ")$")
+		if err != nil {
+			return pattern, fmt.Errorf("invalid matches pattern: %w", err)
+		}
+		pattern.re = re
+	case pattern.DoesNotMatch != nil:
+		re, err := regexp.Compile("^(?:" + *pattern.DoesNotMatch + ")$")
+		if err != nil {
+			return pattern, fmt.Errorf("invalid doesNotMatch pattern: %w", err)
+		}
+		pattern.re = re
+	case pattern.EqualToJSON != nil:
+		expected := []byte(pattern.EqualToJSON)
+		// the expected JSON can be set either as a JSON value or as a string holding it
+		var text string
+		if err := json.Unmarshal(expected, &text); err == nil {
+			expected = []byte(text)
+		}
+		expectedJSON, err := decodeJSON(expected)
+		if err != nil {
+			return pattern, fmt.Errorf("invalid equalToJson pattern: %w", err)
+		}
+		pattern.expectedJSON = expectedJSON
+		if pattern.IgnoreArrayOrder {
+			return pattern, errors.New("unsupported equalToJson pattern: ignoreArrayOrder")
+		}
+	case pattern.EqualTo == nil && pattern.Contains == nil && !pattern.Absent:
+		return pattern, fmt.Errorf("unsupported pattern %s", data)
+	}
+
+	return pattern, nil
+}
+
+// match checks if any of the values matches the pattern
+func (p *wireMockPattern) match(values []string) bool {
+	if p.Absent {
+		return len(values) == 0
+	}
+
+	for _, value := range values {
+		if p.matchValue(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *wireMockPattern) matchValue(value string) bool {
+	switch {
+	case p.EqualTo != nil && p.CaseInsensitive:
+		return strings.EqualFold(value, *p.EqualTo)
+	case p.EqualTo != nil:
+		return value == *p.EqualTo
+	case p.Contains != nil:
+		return strings.Contains(value, *p.Contains)
+	case p.Matches != nil:
+		return p.re.MatchString(value)
+	case p.DoesNotMatch != nil:
+		return !p.re.MatchString(value)
+	case p.expectedJSON != nil:
+		actual, err := decodeJSON([]byte(value))
+		if err != nil {
+			return false
+		}
+		if p.IgnoreExtraElements {
+			return jsonContains(p.expectedJSON, actual)
+		}
+		return len(jsonDiff(p.expectedJSON, actual, "$", false)) == 0
+	default:
+		return false
+	}
+}
+
+// String describes the pattern
+func (p *wireMockPattern) String() string {
+	switch {
+	case p.Absent:
+		return "absent"
+	case p.EqualTo != nil:
+		return "equalTo " + *p.EqualTo
+	case p.Contains != nil:
+		return "contains " + *p.Contains
+	case p.Matches != nil:
+		return "matches " + *p.Matches
+	case p.DoesNotMatch != nil:
+		return "doesNotMatch " + *p.DoesNotMatch
+	default:
+		return "equalToJson " + string(p.EqualToJSON)
+	}
+}
+
+// wireMockMatcher provides a Matcher for a WireMock pattern
+// against a header, a query parameter or the body of the request
+type wireMockMatcher struct {
+	Source  string
+	Key     string
+	Pattern wireMockPattern
+}
+
+// Match checks if the header, query parameter or body matches the pattern
+func (m *wireMockMatcher) Match(r *http.Request) bool {
+	switch m.Source {
+	case "header":
+		return m.Pattern.match(r.Header[http.CanonicalHeaderKey(m.Key)])
+	case "query":
+		return m.Pattern.match(r.URL.Query()[m.Key])
+	default:
+		body, err := readBody(r)
+		if err != nil {
+			return false
+		}
+		return m.Pattern.match([]string{string(body)})
+	}
+}
+
+// String describes the wireMockMatcher
+func (m *wireMockMatcher) String() string {
+	if m.Key == "" {
+		return fmt.Sprintf("%s %s", m.Source, m.Pattern.String())
+	}
+	return fmt.Sprintf("%s %s %s", m.Source, m.Key, m.Pattern.String())
+}
+